@@ -27,12 +27,16 @@ package main
 import (
 	"log/slog"
 
+	"github.com/tradalia/core"
+	"github.com/tradalia/core/auth"
 	"github.com/tradalia/core/boot"
 	"github.com/tradalia/core/msg"
 	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/app"
+	"github.com/tradalia/inventory-server/pkg/core/messaging/command"
 	"github.com/tradalia/inventory-server/pkg/core/messaging/system"
 	"github.com/tradalia/inventory-server/pkg/core/process"
+	"github.com/tradalia/inventory-server/pkg/core/vault"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"github.com/tradalia/inventory-server/pkg/service"
 )
@@ -49,8 +53,11 @@ func main() {
 	logger := boot.InitLogger(component, &cfg.Application)
 	engine := boot.InitEngine(logger,    &cfg.Application)
 	initClients()
+	auth.InitAuthentication(&cfg.Authentication)
 	db.InitDatabase(&cfg.Database)
+	core.ExitIfError(vault.Init(&cfg.Security.Encryption))
 	msg.InitMessaging(&cfg.Messaging)
+	command.Init(&cfg.Messaging)
 	service.Init(engine, cfg, logger)
 	process.Init(cfg)
 	system.InitMessageListener()
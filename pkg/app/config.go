@@ -29,8 +29,10 @@ import "github.com/tradalia/core"
 //=============================================================================
 
 type Currency struct {
-	BaseUrl string `json:"baseUrl"`
-	ApiKey  string `json:"apiKey"`
+	BaseUrl          string  `json:"baseUrl"`
+	ApiKey           string  `json:"apiKey"`
+	AnomalyThreshold float64 `json:"anomalyThreshold"`
+	AnomalyDays      int     `json:"anomalyDays"`
 }
 
 //=============================================================================
@@ -41,6 +43,29 @@ type Provider struct {
 
 //=============================================================================
 
+//--- Keys are base64 encoded and 32 bytes long (AES-256). Old keys are kept to
+//--- decrypt values not yet re-encrypted with the active key
+
+type EncryptionKey struct {
+	Id  string `json:"id"`
+	Key string `json:"key"`
+}
+
+//-----------------------------------------------------------------------------
+
+type Encryption struct {
+	ActiveKey string          `json:"activeKey"`
+	Keys      []EncryptionKey `json:"keys"`
+}
+
+//=============================================================================
+
+type Security struct {
+	Encryption Encryption `json:"encryption"`
+}
+
+//=============================================================================
+
 type Config struct {
 	core.Application
 	core.Database
@@ -48,6 +73,7 @@ type Config struct {
 	core.Platform
 	core.Messaging
 	Provider
+	Security
 }
 
 //=============================================================================
@@ -26,24 +26,52 @@ package business
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
 
 //=============================================================================
 
-func GetAgentProfiles(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.AgentProfile, error) {
+func GetAgentProfiles(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.AgentProfile, int64, error) {
 	if ! c.Session.IsAdmin() {
 		filter["username"] = c.Session.Username
 	}
 
+	total, err := db.CountAgentProfiles(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	list,err := db.GetAgentProfiles(tx, filter, offset, limit)
 
 	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+//=============================================================================
+
+func GetAgentProfileById(tx *gorm.DB, c *auth.Context, id uint) (*db.AgentProfile, error) {
+	ap, err := db.GetAgentProfileById(tx, id)
+	if err != nil {
+		c.Log.Error("GetAgentProfileById: Could not retrieve agent profile", "error", err.Error())
 		return nil, err
 	}
 
-	return list, nil
+	if ap == nil {
+		c.Log.Error("GetAgentProfileById: Agent profile was not found", "id", id)
+		return nil, req.NewNotFoundError("Agent profile was not found: %v", id)
+	}
+
+	if ap.Username != c.Session.Username && ! c.Session.IsAdmin() {
+		c.Log.Error("GetAgentProfileById: Agent profile not owned by user", "id", id)
+		return nil, req.NewForbiddenError("Agent profile is not owned by user: %v", id)
+	}
+
+	return ap, nil
 }
 
 //=============================================================================
@@ -0,0 +1,201 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Margin and commission of a broker product are kept as a history of values
+//=== with an effective date. The product stores the values valid today
+//===
+//=============================================================================
+
+func GetBrokerProductCosts(tx *gorm.DB, c *auth.Context, id uint) (*[]db.BrokerProductCost, error) {
+	bp, err := getBrokerProductAndCheckAccess(tx, c, id, "GetBrokerProductCosts")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := db.GetBrokerProductCosts(tx, id)
+	if err != nil {
+		c.Log.Error("GetBrokerProductCosts: Could not retrieve cost history", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if len(*list) == 0 {
+		*list = append(*list, BrokerProductCostAt(bp, nil, datatype.Today(time.UTC)))
+	}
+
+	return list, nil
+}
+
+//=============================================================================
+
+func GetBrokerProductCostAt(tx *gorm.DB, c *auth.Context, id uint, date datatype.IntDate) (*db.BrokerProductCost, error) {
+	bp, err := getBrokerProductAndCheckAccess(tx, c, id, "GetBrokerProductCostAt")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := db.GetBrokerProductCosts(tx, id)
+	if err != nil {
+		c.Log.Error("GetBrokerProductCostAt: Could not retrieve cost history", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	cost := BrokerProductCostAt(bp, *list, date)
+	return &cost, nil
+}
+
+//=============================================================================
+//--- The list must be sorted by date. Dates before the first entry get the
+//--- oldest value, while products without history use their current values
+
+func BrokerProductCostAt(bp *db.BrokerProduct, list []db.BrokerProductCost, date datatype.IntDate) db.BrokerProductCost {
+	if len(list) == 0 {
+		return db.BrokerProductCost{
+			BrokerProductId : bp.Id,
+			EffectiveDate   : datatype.ToIntDate(&bp.CreatedAt),
+			MarginValue     : bp.MarginValue,
+			CostPerOperation: bp.CostPerOperation,
+		}
+	}
+
+	res := list[0]
+
+	for _, bpc := range list {
+		if bpc.EffectiveDate > date {
+			break
+		}
+
+		res = bpc
+	}
+
+	return res
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getEffectiveDate(date datatype.IntDate) (datatype.IntDate, error) {
+	if date.IsNil() {
+		return datatype.Today(time.UTC), nil
+	}
+
+	if !date.IsValid() {
+		return 0, req.NewBadRequestError("Invalid effective date: %v", date)
+	}
+
+	return date, nil
+}
+
+//=============================================================================
+
+func addBrokerProductCost(tx *gorm.DB, c *auth.Context, bp *db.BrokerProduct, date datatype.IntDate) error {
+	bpc := db.BrokerProductCost{
+		BrokerProductId : bp.Id,
+		EffectiveDate   : date,
+		MarginValue     : bp.MarginValue,
+		CostPerOperation: bp.CostPerOperation,
+	}
+
+	err := db.SetBrokerProductCost(tx, &bpc)
+	if err != nil {
+		c.Log.Error("addBrokerProductCost: Could not save cost history", "error", err.Error(), "id", bp.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Records the new values when they differ from the ones in effect at the
+//--- given date, then refreshes the current values of the product
+
+func setBrokerProductCosts(tx *gorm.DB, c *auth.Context, bp *db.BrokerProduct, date datatype.IntDate, margin float32, cost float32) error {
+	list, err := db.GetBrokerProductCosts(tx, bp.Id)
+	if err != nil {
+		c.Log.Error("setBrokerProductCosts: Could not retrieve cost history", "error", err.Error(), "id", bp.Id)
+		return err
+	}
+
+	//--- Products created before the history keep their values as the first entry
+
+	if len(*list) == 0 {
+		seed := BrokerProductCostAt(bp, nil, date)
+		if seed.EffectiveDate != date {
+			err = db.SetBrokerProductCost(tx, &seed)
+			if err != nil {
+				c.Log.Error("setBrokerProductCosts: Could not save cost history", "error", err.Error(), "id", bp.Id)
+				return req.NewServerErrorByError(err)
+			}
+
+			*list = append(*list, seed)
+		}
+	}
+
+	at := BrokerProductCostAt(bp, *list, date)
+
+	if at.EffectiveDate == date || at.MarginValue != margin || at.CostPerOperation != cost {
+		bpc := db.BrokerProductCost{
+			BrokerProductId : bp.Id,
+			EffectiveDate   : date,
+			MarginValue     : margin,
+			CostPerOperation: cost,
+		}
+
+		err = db.SetBrokerProductCost(tx, &bpc)
+		if err != nil {
+			c.Log.Error("setBrokerProductCosts: Could not save cost history", "error", err.Error(), "id", bp.Id)
+			return req.NewServerErrorByError(err)
+		}
+
+		list, err = db.GetBrokerProductCosts(tx, bp.Id)
+		if err != nil {
+			c.Log.Error("setBrokerProductCosts: Could not retrieve cost history", "error", err.Error(), "id", bp.Id)
+			return err
+		}
+	}
+
+	current := BrokerProductCostAt(bp, *list, datatype.Today(time.UTC))
+	bp.MarginValue      = current.MarginValue
+	bp.CostPerOperation = current.CostPerOperation
+
+	return nil
+}
+
+//=============================================================================
@@ -25,7 +25,10 @@ THE SOFTWARE.
 package business
 
 import (
+	"time"
+
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
 	"github.com/tradalia/core/msg"
 	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/db"
@@ -34,16 +37,32 @@ import (
 
 //=============================================================================
 
-func GetBrokerProducts(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int, details bool) (*[]db.BrokerProductFull, error) {
+func GetBrokerProducts(tx *gorm.DB, c *auth.Context, q *db.ListQuery, offset int, limit int, details bool) (*[]db.BrokerProductFull, int64, error) {
 	if ! c.Session.IsAdmin() {
-		filter["username"] = c.Session.Username
+		q.Filter["username"] = c.Session.Username
+	}
+
+	total, err := db.CountBrokerProducts(tx, q)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	if details {
-		return db.GetBrokerProductsFull(tx, filter, offset, limit)
+		list, err := db.GetBrokerProductsFull(tx, q, offset, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		err = addReportingValues(tx, c, list)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return list, total, nil
 	}
 
-	return db.GetBrokerProducts(tx, filter, offset, limit)
+	list, err := db.GetBrokerProducts(tx, q, offset, limit)
+	return list, total, err
 }
 
 //=============================================================================
@@ -64,6 +83,11 @@ func GetBrokerProductById(tx *gorm.DB, c *auth.Context, id uint) (*BrokerProduct
 		return nil, err
 	}
 
+	err = maskConnection(c, conn)
+	if err != nil {
+		return nil, err
+	}
+
 	//--- Get exchange
 
 	ex, err := db.GetExchangeById(tx, bp.ExchangeId)
@@ -88,6 +112,16 @@ func GetBrokerProductById(tx *gorm.DB, c *auth.Context, id uint) (*BrokerProduct
 func AddBrokerProduct(tx *gorm.DB, c *auth.Context, bps *BrokerProductSpec) (*db.BrokerProduct, error) {
 	c.Log.Info("AddBrokerProduct: Adding a new broker product", "symbol", bps.Symbol, "name", bps.Name)
 
+	err := checkCatalogProduct(tx, c, bps.CatalogProductId, "AddBrokerProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveDate, err := getEffectiveDate(bps.EffectiveDate)
+	if err != nil {
+		return nil, err
+	}
+
 	var pb db.BrokerProduct
 	pb.ConnectionId     = bps.ConnectionId
 	pb.ExchangeId       = bps.ExchangeId
@@ -100,14 +134,20 @@ func AddBrokerProduct(tx *gorm.DB, c *auth.Context, bps *BrokerProductSpec) (*db
 	pb.Increment        = bps.Increment
 	pb.MarketType       = bps.MarketType
 	pb.ProductType      = bps.ProductType
+	pb.CatalogProductId = bps.CatalogProductId
 
-	err := db.AddBrokerProduct(tx, &pb)
+	err = db.AddBrokerProduct(tx, &pb)
 
 	if err != nil {
 		c.Log.Error("AddBrokerProduct: Could not add a new broker product", "error", err.Error())
 		return nil, err
 	}
 
+	err = addBrokerProductCost(tx, c, &pb, effectiveDate)
+	if err != nil {
+		return nil, err
+	}
+
 	err = sendBrokerProductChangeMessage(tx, c, &pb, msg.TypeCreate)
 	if err != nil {
 		return nil, err
@@ -127,16 +167,38 @@ func UpdateBrokerProduct(tx *gorm.DB, c *auth.Context, id uint, pbs *BrokerProdu
 		return nil, err
 	}
 
+	err = checkCatalogProduct(tx, c, pbs.CatalogProductId, "UpdateBrokerProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveDate, err := getEffectiveDate(pbs.EffectiveDate)
+	if err != nil {
+		return nil, err
+	}
+
 	pb.ExchangeId      = pbs.ExchangeId
 	pb.Symbol          = pbs.Symbol
 	pb.Name            = pbs.Name
 	pb.PointValue      = pbs.PointValue
-	pb.CostPerOperation= pbs.CostPerOperation
-	pb.MarginValue     = pbs.MarginValue
 	pb.Increment       = pbs.Increment
 	pb.MarketType      = pbs.MarketType
 	pb.ProductType     = pbs.ProductType
 
+	//--- A missing catalog product keeps the current link
+
+	if pbs.CatalogProductId != nil {
+		pb.CatalogProductId = pbs.CatalogProductId
+	}
+
+	//--- Margin and commission are set from the history, as the new values
+	//--- might not be effective yet
+
+	err = setBrokerProductCosts(tx, c, pb, effectiveDate, pbs.MarginValue, pbs.CostPerOperation)
+	if err != nil {
+		return nil, err
+	}
+
 	err = db.UpdateBrokerProduct(tx, pb)
 	if err != nil {
 		return nil, err
@@ -182,6 +244,58 @@ func getBrokerProductAndCheckAccess(tx *gorm.DB, c *auth.Context, id uint, funct
 
 //=============================================================================
 
+//--- Margin and costs are shown in the user's reporting currency too, using the latest rate
+
+func addReportingValues(tx *gorm.DB, c *auth.Context, list *[]db.BrokerProductFull) error {
+	reporting, err := GetReportingCurrency(tx, c.Session.Username)
+	if err != nil {
+		c.Log.Error("GetBrokerProducts: Could not retrieve reporting currency", "error", err.Error())
+		return req.NewServerErrorByError(err)
+	}
+
+	cc         := NewCurrencyConverter(tx)
+	today      := datatype.Today(time.UTC)
+	currencies := map[string]*db.Currency{}
+
+	for i := range *list {
+		bp := &(*list)[i]
+
+		cu, found := currencies[bp.CurrencyCode]
+		if !found {
+			cu, err = db.GetCurrencyByCode(tx, bp.CurrencyCode)
+			if err != nil {
+				c.Log.Error("GetBrokerProducts: Could not retrieve currency", "error", err.Error(), "code", bp.CurrencyCode)
+				return err
+			}
+			currencies[bp.CurrencyCode] = cu
+		}
+
+		if cu == nil {
+			continue
+		}
+
+		margin, err := cc.Convert(float64(bp.MarginValue), cu, reporting, today)
+		if err != nil {
+			c.Log.Warn("GetBrokerProducts: Cannot convert to reporting currency", "error", err.Error(), "id", bp.Id)
+			continue
+		}
+
+		cost, err := cc.Convert(float64(bp.CostPerOperation), cu, reporting, today)
+		if err != nil {
+			c.Log.Warn("GetBrokerProducts: Cannot convert to reporting currency", "error", err.Error(), "id", bp.Id)
+			continue
+		}
+
+		bp.ReportingCurrencyCode     = reporting.Code
+		bp.ReportingMarginValue      = float32(margin)
+		bp.ReportingCostPerOperation = float32(cost)
+	}
+
+	return nil
+}
+
+//=============================================================================
+
 func sendBrokerProductChangeMessage(tx *gorm.DB, c *auth.Context, pb *db.BrokerProduct, msgType int) error {
 
 	var exc *db.Exchange
@@ -205,7 +319,15 @@ func sendBrokerProductChangeMessage(tx *gorm.DB, c *auth.Context, pb *db.BrokerP
 		return err
 	}
 
-	pbm := BrokerProductMessage{*pb, *conn, *exc, *cur }
+	cme, err := getCommissionModelExt(tx, pb.Id)
+	if err != nil {
+		c.Log.Error("[Add|Update]BrokerProduct: Could not retrieve commission model", "error", err.Error())
+		return err
+	}
+
+	conn.SystemConfigParams = redactSystemConfigParams(conn.SystemConfigParams)
+
+	pbm := BrokerProductMessage{*pb, *conn, *exc, *cur, cme }
 	err = msg.SendMessage(msg.ExInventory, msg.SourceBrokerProduct, msgType, &pbm)
 
 	if err != nil {
@@ -0,0 +1,330 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetCatalogProducts(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]db.CatalogProduct, int64, error) {
+	total, err := db.CountCatalogProducts(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := db.GetCatalogProducts(tx, filter, offset, limit)
+	return list, total, err
+}
+
+//=============================================================================
+
+func GetCatalogProductById(tx *gorm.DB, c *auth.Context, id uint) (*db.CatalogProduct, error) {
+	return getCatalogProduct(tx, c, id, "GetCatalogProductById")
+}
+
+//=============================================================================
+
+func AddCatalogProduct(tx *gorm.DB, c *auth.Context, cps *CatalogProductSpec) (*db.CatalogProduct, error) {
+	c.Log.Info("AddCatalogProduct: Adding a new catalog product", "symbol", cps.Symbol, "name", cps.Name)
+
+	err := validateCatalogProductSpec(tx, c, 0, cps, "AddCatalogProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	var cp db.CatalogProduct
+	setCatalogProductFields(&cp, cps)
+
+	err = db.AddCatalogProduct(tx, &cp)
+	if err != nil {
+		c.Log.Error("AddCatalogProduct: Could not add a new catalog product", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendCatalogProductChangeMessage(tx, c, &cp, msg.TypeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("AddCatalogProduct: Catalog product added", "symbol", cp.Symbol, "id", cp.Id)
+	return &cp, nil
+}
+
+//=============================================================================
+//--- Linked products keep their values: owners are notified through the
+//--- change message and decide whether to align them
+
+func UpdateCatalogProduct(tx *gorm.DB, c *auth.Context, id uint, cps *CatalogProductSpec) (*db.CatalogProduct, error) {
+	c.Log.Info("UpdateCatalogProduct: Updating a catalog product", "id", id, "name", cps.Name)
+
+	cp, err := getCatalogProduct(tx, c, id, "UpdateCatalogProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateCatalogProductSpec(tx, c, id, cps, "UpdateCatalogProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	setCatalogProductFields(cp, cps)
+
+	err = db.UpdateCatalogProduct(tx, cp)
+	if err != nil {
+		c.Log.Error("UpdateCatalogProduct: Could not update the catalog product", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendCatalogProductChangeMessage(tx, c, cp, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("UpdateCatalogProduct: Catalog product updated", "id", cp.Id, "symbol", cp.Symbol)
+	return cp, nil
+}
+
+//=============================================================================
+
+func DeleteCatalogProduct(tx *gorm.DB, c *auth.Context, id uint) (*db.CatalogProduct, error) {
+	c.Log.Info("DeleteCatalogProduct: Deleting catalog product", "id", id)
+
+	cp, err := getCatalogProduct(tx, c, id, "DeleteCatalogProduct")
+	if err != nil {
+		return nil, err
+	}
+
+	bps, err := db.GetBrokerProductsByCatalogProductId(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteCatalogProduct: Could not retrieve broker products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	dps, err := db.GetDataProductsByCatalogProductId(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteCatalogProduct: Could not retrieve data products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if len(*bps) > 0 || len(*dps) > 0 {
+		c.Log.Error("DeleteCatalogProduct: Catalog product is still referenced by products", "id", id, "brokerProducts", len(*bps), "dataProducts", len(*dps))
+		return nil, req.NewUnprocessableEntityError("Catalog product is still referenced by products: %v", id)
+	}
+
+	err = db.DeleteCatalogProduct(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteCatalogProduct: Cannot delete catalog product", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendCatalogProductChangeMessage(tx, c, cp, msg.TypeDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("DeleteCatalogProduct: Catalog product deleted", "id", id, "symbol", cp.Symbol)
+	return cp, nil
+}
+
+//=============================================================================
+
+func AddDataProductFromCatalog(tx *gorm.DB, c *auth.Context, id uint, cdps *CatalogDataProductSpec) (*db.DataProduct, error) {
+	cp, err := getCatalogProduct(tx, c, id, "AddDataProductFromCatalog")
+	if err != nil {
+		return nil, err
+	}
+
+	pds := DataProductSpec{
+		ConnectionId    : cdps.ConnectionId,
+		ExchangeId      : cp.ExchangeId,
+		Symbol          : cp.Symbol,
+		Name            : cp.Name,
+		MarketType      : cp.MarketType,
+		ProductType     : cp.ProductType,
+		Months          : cp.Months,
+		RolloverTrigger : cdps.RolloverTrigger,
+		CatalogProductId: &cp.Id,
+	}
+
+	if cdps.Symbol != "" {
+		pds.Symbol = cdps.Symbol
+	}
+
+	if cdps.Name != "" {
+		pds.Name = cdps.Name
+	}
+
+	return AddDataProduct(tx, c, &pds)
+}
+
+//=============================================================================
+
+func AddBrokerProductFromCatalog(tx *gorm.DB, c *auth.Context, id uint, cbps *CatalogBrokerProductSpec) (*db.BrokerProduct, error) {
+	cp, err := getCatalogProduct(tx, c, id, "AddBrokerProductFromCatalog")
+	if err != nil {
+		return nil, err
+	}
+
+	bps := BrokerProductSpec{
+		ConnectionId    : cbps.ConnectionId,
+		ExchangeId      : cp.ExchangeId,
+		Symbol          : cp.Symbol,
+		Name            : cp.Name,
+		PointValue      : cp.PointValue,
+		CostPerOperation: cbps.CostPerOperation,
+		MarginValue     : cp.MarginValue,
+		Increment       : cp.Increment,
+		MarketType      : cp.MarketType,
+		ProductType     : cp.ProductType,
+		CatalogProductId: &cp.Id,
+	}
+
+	if cbps.Symbol != "" {
+		bps.Symbol = cbps.Symbol
+	}
+
+	if cbps.Name != "" {
+		bps.Name = cbps.Name
+	}
+
+	return AddBrokerProduct(tx, c, &bps)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getCatalogProduct(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.CatalogProduct, error) {
+	cp, err := db.GetCatalogProductById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve catalog product", "error", err.Error())
+		return nil, err
+	}
+
+	if cp == nil {
+		c.Log.Error(function +": Catalog product was not found", "id", id)
+		return nil, req.NewNotFoundError("Catalog product was not found: %v", id)
+	}
+
+	return cp, nil
+}
+
+//=============================================================================
+//--- A nil id means that the product is not linked to the catalog
+
+func checkCatalogProduct(tx *gorm.DB, c *auth.Context, id *uint, function string) error {
+	if id == nil {
+		return nil
+	}
+
+	_, err := getCatalogProduct(tx, c, *id, function)
+	return err
+}
+
+//=============================================================================
+
+func validateCatalogProductSpec(tx *gorm.DB, c *auth.Context, id uint, cps *CatalogProductSpec, function string) error {
+	_, err := getExchange(tx, c, cps.ExchangeId, function)
+	if err != nil {
+		return err
+	}
+
+	cp, err := db.GetCatalogProductBySymbol(tx, cps.ExchangeId, cps.Symbol)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve catalog product by symbol", "error", err.Error())
+		return err
+	}
+
+	if cp != nil && cp.Id != id {
+		c.Log.Error(function +": Catalog symbol already in use", "symbol", cps.Symbol)
+		return req.NewBadRequestError("Catalog symbol already in use: %v", cps.Symbol)
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func setCatalogProductFields(cp *db.CatalogProduct, cps *CatalogProductSpec) {
+	cp.ExchangeId  = cps.ExchangeId
+	cp.Symbol      = cps.Symbol
+	cp.Name        = cps.Name
+	cp.PointValue  = cps.PointValue
+	cp.Increment   = cps.Increment
+	cp.Months      = cps.Months
+	cp.MarginValue = cps.MarginValue
+	cp.MarketType  = cps.MarketType
+	cp.ProductType = cps.ProductType
+}
+
+//=============================================================================
+
+func sendCatalogProductChangeMessage(tx *gorm.DB, c *auth.Context, cp *db.CatalogProduct, msgType int) error {
+	ex, err := db.GetExchangeById(tx, cp.ExchangeId)
+	if err != nil {
+		c.Log.Error("sendCatalogProductChangeMessage: Could not retrieve exchange", "error", err.Error(), "id", cp.Id)
+		return err
+	}
+
+	dps, err := db.GetDataProductsByCatalogProductId(tx, cp.Id)
+	if err != nil {
+		c.Log.Error("sendCatalogProductChangeMessage: Could not retrieve data products", "error", err.Error(), "id", cp.Id)
+		return err
+	}
+
+	bps, err := db.GetBrokerProductsByCatalogProductId(tx, cp.Id)
+	if err != nil {
+		c.Log.Error("sendCatalogProductChangeMessage: Could not retrieve broker products", "error", err.Error(), "id", cp.Id)
+		return err
+	}
+
+	cpm := CatalogProductMessage{
+		CatalogProduct: *cp,
+		DataProducts  : *dps,
+		BrokerProducts: *bps,
+	}
+
+	if ex != nil {
+		cpm.Exchange = *ex
+	}
+
+	err = msg.SendMessage(msg.ExInventory, SourceCatalogProduct, msgType, &cpm)
+	if err != nil {
+		c.Log.Error("sendCatalogProductChangeMessage: Could not publish the change message", "error", err.Error(), "id", cp.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
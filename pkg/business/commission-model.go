@@ -0,0 +1,348 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"math"
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+const CommissionModelLegacy = "cost-per-operation"
+
+//=============================================================================
+
+func GetCommissionModel(tx *gorm.DB, c *auth.Context, id uint) (*CommissionModelExt, error) {
+	_, err := getBrokerProductAndCheckAccess(tx, c, id, "GetCommissionModel")
+	if err != nil {
+		return nil, err
+	}
+
+	cme, err := getCommissionModelExt(tx, id)
+	if err != nil {
+		c.Log.Error("GetCommissionModel: Could not retrieve commission model", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if cme == nil {
+		return nil, req.NewNotFoundError("Broker product has no commission model: %v", id)
+	}
+
+	return cme, nil
+}
+
+//=============================================================================
+
+func SetCommissionModel(tx *gorm.DB, c *auth.Context, id uint, cms *CommissionModelSpec) (*CommissionModelExt, error) {
+	c.Log.Info("SetCommissionModel: Setting commission model", "id", id, "type", cms.Type)
+
+	bp, err := getBrokerProductAndCheckAccess(tx, c, id, "SetCommissionModel")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateCommissionModelSpec(tx, c, cms, "SetCommissionModel")
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := db.GetCommissionModelByBrokerProductId(tx, id)
+	if err != nil {
+		c.Log.Error("SetCommissionModel: Could not retrieve commission model", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if cm == nil {
+		cm = &db.CommissionModel{ BrokerProductId: id }
+	}
+
+	cm.Type          = cms.Type
+	cm.CurrencyId    = cms.CurrencyId
+	cm.Fixed         = cms.Fixed
+	cm.PerContract   = cms.PerContract
+	cm.Percentage    = cms.Percentage
+	cm.ExchangeFee   = cms.ExchangeFee
+	cm.MinCommission = cms.MinCommission
+	cm.MaxCommission = cms.MaxCommission
+
+	err = db.SetCommissionModel(tx, cm)
+	if err != nil {
+		c.Log.Error("SetCommissionModel: Could not save commission model", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	var tiers []db.CommissionTier
+	if cm.Type == db.CommissionTypeTiered {
+		for _, t := range cms.Tiers {
+			tiers = append(tiers, db.CommissionTier{
+				FromContracts: t.FromContracts,
+				PerContract  : t.PerContract,
+			})
+		}
+	}
+
+	err = db.SetCommissionTiers(tx, cm.Id, tiers)
+	if err != nil {
+		c.Log.Error("SetCommissionModel: Could not save commission tiers", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendBrokerProductChangeMessage(tx, c, bp, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("SetCommissionModel: Commission model set", "id", id, "modelId", cm.Id)
+	return getCommissionModelExt(tx, id)
+}
+
+//=============================================================================
+
+func DeleteCommissionModel(tx *gorm.DB, c *auth.Context, id uint) (*db.CommissionModel, error) {
+	c.Log.Info("DeleteCommissionModel: Deleting commission model", "id", id)
+
+	bp, err := getBrokerProductAndCheckAccess(tx, c, id, "DeleteCommissionModel")
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := db.GetCommissionModelByBrokerProductId(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteCommissionModel: Could not retrieve commission model", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if cm == nil {
+		return nil, req.NewNotFoundError("Broker product has no commission model: %v", id)
+	}
+
+	err = db.DeleteCommissionModel(tx, cm.Id)
+	if err != nil {
+		c.Log.Error("DeleteCommissionModel: Could not delete commission model", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendBrokerProductChangeMessage(tx, c, bp, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("DeleteCommissionModel: Commission model deleted", "id", id)
+	return cm, nil
+}
+
+//=============================================================================
+//--- The price is needed by percentage models only, to compute the notional
+
+func CalculateCommission(tx *gorm.DB, c *auth.Context, id uint, contracts int, price float64) (*CommissionQuote, error) {
+	bp, err := getBrokerProductAndCheckAccess(tx, c, id, "CalculateCommission")
+	if err != nil {
+		return nil, err
+	}
+
+	if contracts < 1 {
+		return nil, req.NewBadRequestError("Contracts must be at least 1: %v", contracts)
+	}
+
+	cme, err := getCommissionModelExt(tx, id)
+	if err != nil {
+		c.Log.Error("CalculateCommission: Could not retrieve commission model", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	quote := CommissionQuote{
+		BrokerProductId: id,
+		Contracts      : contracts,
+		Price          : price,
+	}
+
+	var currency *db.Currency
+
+	if cme == nil {
+		ex, err := getExchange(tx, c, bp.ExchangeId, "CalculateCommission")
+		if err != nil {
+			return nil, err
+		}
+
+		currency, err = db.GetCurrencyById(tx, ex.CurrencyId)
+		if err != nil {
+			c.Log.Error("CalculateCommission: Could not retrieve currency", "error", err.Error(), "id", id)
+			return nil, err
+		}
+
+		quote.Model      = CommissionModelLegacy
+		quote.Commission = float64(bp.CostPerOperation) * float64(contracts)
+	} else {
+		if cme.Type == db.CommissionTypePercentage && price <= 0 {
+			return nil, req.NewBadRequestError("Price is required by percentage commissions: %v", price)
+		}
+
+		currency = &cme.Currency
+
+		quote.Model      = string(cme.Type)
+		quote.Commission = computeCommission(&cme.CommissionModel, cme.Tiers, float64(bp.PointValue), contracts, price)
+	}
+
+	if currency == nil {
+		return nil, req.NewNotFoundError("Currency of broker product was not found: %v", id)
+	}
+
+	quote.CurrencyCode = currency.Code
+
+	reporting, err := GetReportingCurrency(tx, c.Session.Username)
+	if err != nil {
+		c.Log.Error("CalculateCommission: Could not retrieve reporting currency", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	amount, err := NewCurrencyConverter(tx).Convert(quote.Commission, currency, reporting, datatype.Today(time.UTC))
+	if err != nil {
+		c.Log.Warn("CalculateCommission: Cannot convert to reporting currency", "error", err.Error(), "id", id)
+	} else {
+		quote.ReportingCommission   = amount
+		quote.ReportingCurrencyCode = reporting.Code
+	}
+
+	return &quote, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getCommissionModelExt(tx *gorm.DB, id uint) (*CommissionModelExt, error) {
+	cm, err := db.GetCommissionModelByBrokerProductId(tx, id)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+
+	cu, err := db.GetCurrencyById(tx, cm.CurrencyId)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers, err := db.GetCommissionTiers(tx, cm.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	cme := CommissionModelExt{
+		CommissionModel: *cm,
+		Tiers          : *tiers,
+	}
+
+	if cu != nil {
+		cme.Currency = *cu
+	}
+
+	return &cme, nil
+}
+
+//=============================================================================
+
+func validateCommissionModelSpec(tx *gorm.DB, c *auth.Context, cms *CommissionModelSpec, function string) error {
+	cu, err := db.GetCurrencyById(tx, cms.CurrencyId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve currency", "error", err.Error())
+		return err
+	}
+
+	if cu == nil {
+		return req.NewNotFoundError("Currency was not found: %v", cms.CurrencyId)
+	}
+
+	if cms.MaxCommission > 0 && cms.MaxCommission < cms.MinCommission {
+		return req.NewBadRequestError("Maximum commission is lower than the minimum: %v", cms.MaxCommission)
+	}
+
+	if cms.Type != db.CommissionTypeTiered {
+		return nil
+	}
+
+	if len(cms.Tiers) == 0 || cms.Tiers[0].FromContracts != 1 {
+		return req.NewBadRequestError("Tiers must start from one contract: %v", len(cms.Tiers))
+	}
+
+	for i := 1; i < len(cms.Tiers); i++ {
+		if cms.Tiers[i].FromContracts <= cms.Tiers[i-1].FromContracts {
+			return req.NewBadRequestError("Tiers must be sorted by contracts: %v", cms.Tiers[i].FromContracts)
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Tiers are graduated: each contract is charged at the rate of its tier
+
+func computeCommission(cm *db.CommissionModel, tiers []db.CommissionTier, pointValue float64, contracts int, price float64) float64 {
+	var commission float64
+
+	switch cm.Type {
+	case db.CommissionTypeFixed:
+		commission = cm.Fixed
+	case db.CommissionTypePerContract:
+		commission = cm.Fixed + cm.PerContract * float64(contracts)
+	case db.CommissionTypePercentage:
+		commission = cm.Fixed + price * pointValue * float64(contracts) * cm.Percentage / 100
+	case db.CommissionTypeTiered:
+		commission = cm.Fixed
+
+		for i, t := range tiers {
+			last := contracts
+			if i+1 < len(tiers) && tiers[i+1].FromContracts - 1 < last {
+				last = tiers[i+1].FromContracts - 1
+			}
+
+			if last >= t.FromContracts {
+				commission += t.PerContract * float64(last - t.FromContracts + 1)
+			}
+		}
+	}
+
+	commission += cm.ExchangeFee * float64(contracts)
+
+	if cm.MinCommission > 0 {
+		commission = math.Max(commission, cm.MinCommission)
+	}
+
+	if cm.MaxCommission > 0 {
+		commission = math.Min(commission, cm.MaxCommission)
+	}
+
+	return commission
+}
+
+//=============================================================================
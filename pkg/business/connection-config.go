@@ -0,0 +1,383 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tradalia/inventory-server/pkg/core/vault"
+	"github.com/tradalia/inventory-server/pkg/platform"
+)
+
+//=============================================================================
+//===
+//=== The system config params of a connection are a JSON object. Systems can
+//=== describe their params: in that case params are validated and secret ones
+//=== are stored encrypted. Secrets are masked when connections leave the
+//=== inventory, the only exception being the system adapter
+//===
+//=============================================================================
+
+const SecretMask = "********"
+
+//=============================================================================
+
+type ConfigParamError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+//-----------------------------------------------------------------------------
+
+type ConfigParamsError struct {
+	Errors []ConfigParamError
+}
+
+//-----------------------------------------------------------------------------
+
+func (e ConfigParamsError) Error() string {
+	var list []string
+	for _, pe := range e.Errors {
+		list = append(list, pe.Param +": "+ pe.Message)
+	}
+
+	return "Invalid system config params: "+ strings.Join(list, ", ")
+}
+
+//=============================================================================
+
+type ReencryptionResult struct {
+	Connections int `json:"connections"`
+	Updated     int `json:"updated"`
+}
+
+//=============================================================================
+//--- Used by the system adapter, which needs the secrets to connect
+
+func DecryptSystemConfigParams(params string) (string, error) {
+	values, err := parseSystemConfigParams(params)
+	if err != nil {
+		return params, nil
+	}
+
+	decrypted := false
+
+	for name, value := range values {
+		if s, ok := value.(string); ok && vault.IsEncrypted(s) {
+			values[name], err = vault.Decrypt(s)
+			if err != nil {
+				return "", err
+			}
+
+			decrypted = true
+		}
+	}
+
+	if !decrypted {
+		return params, nil
+	}
+
+	return formatSystemConfigParams(values, params), nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func validateSystemConfigParams(sys *platform.System, params string) error {
+	if sys.ConfigParams == nil {
+		return nil
+	}
+
+	values, err := parseSystemConfigParams(params)
+	if err != nil {
+		return ConfigParamsError{ Errors: []ConfigParamError{{ Param: "systemConfigParams", Message: "must be a JSON object" }}}
+	}
+
+	var errs []ConfigParamError
+
+	for _, cp := range sys.ConfigParams {
+		value, ok := values[cp.Name]
+		if !ok || value == nil {
+			if cp.Required {
+				errs = append(errs, ConfigParamError{ Param: cp.Name, Message: "is required" })
+			}
+			continue
+		}
+
+		message := validateConfigParamValue(&cp, value)
+		if message != "" {
+			errs = append(errs, ConfigParamError{ Param: cp.Name, Message: message })
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !slices.ContainsFunc(sys.ConfigParams, func(cp platform.ConfigParam) bool { return cp.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, ConfigParamError{ Param: name, Message: "is not a parameter of system "+ sys.Code })
+	}
+
+	if len(errs) > 0 {
+		return ConfigParamsError{ Errors: errs }
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func validateConfigParamValue(cp *platform.ConfigParam, value any) string {
+	switch cp.Type {
+	case platform.ConfigParamInt:
+		v, ok := value.(float64)
+		if !ok || v != math.Trunc(v) {
+			return "must be an integer"
+		}
+
+	case platform.ConfigParamFloat:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+
+	case platform.ConfigParamBool:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+
+		if len(cp.Options) > 0 && !slices.Contains(cp.Options, v) {
+			return "must be one of "+ strings.Join(cp.Options, ", ")
+		}
+	}
+
+	return ""
+}
+
+//=============================================================================
+
+func maskSystemConfigParams(sys *platform.System, params string) string {
+	values, err := parseSystemConfigParams(params)
+	if err != nil {
+		return params
+	}
+
+	masked := false
+
+	for name, value := range values {
+		if isSecretConfigParam(sys, name, value) {
+			values[name] = SecretMask
+			masked       = true
+		}
+	}
+
+	if !masked {
+		return params
+	}
+
+	return formatSystemConfigParams(values, params)
+}
+
+//=============================================================================
+//--- Used when the system is not at hand: only encrypted values are masked
+
+func redactSystemConfigParams(params string) string {
+	return maskSystemConfigParams(nil, params)
+}
+
+//=============================================================================
+
+func encryptSystemConfigParams(sys *platform.System, params string) (string, error) {
+	values, err := parseSystemConfigParams(params)
+	if err != nil || !vault.IsEnabled() || !hasSecretConfigParams(sys) {
+		return params, nil
+	}
+
+	encrypted := false
+
+	for _, cp := range sys.ConfigParams {
+		if s, ok := values[cp.Name].(string); ok && cp.Secret && !vault.IsEncrypted(s) {
+			values[cp.Name], err = vault.Encrypt(s)
+			if err != nil {
+				return "", err
+			}
+
+			encrypted = true
+		}
+	}
+
+	if !encrypted {
+		return params, nil
+	}
+
+	return formatSystemConfigParams(values, params), nil
+}
+
+//=============================================================================
+//--- Re-encrypts the values whose data key uses an old master key
+
+func rotateSystemConfigParams(params string) (string, bool, error) {
+	values, err := parseSystemConfigParams(params)
+	if err != nil {
+		return params, false, nil
+	}
+
+	rotated := false
+
+	for name, value := range values {
+		if s, ok := value.(string); ok && vault.NeedsRotation(s) {
+			values[name], err = vault.Rotate(s)
+			if err != nil {
+				return "", false, err
+			}
+
+			rotated = true
+		}
+	}
+
+	if !rotated {
+		return params, false, nil
+	}
+
+	return formatSystemConfigParams(values, params), true, nil
+}
+
+//=============================================================================
+//--- Masked secrets sent back by clients keep the value already stored
+
+func mergeSystemConfigParams(sys *platform.System, params string, oldParams string) string {
+	values, err := parseSystemConfigParams(params)
+	if err != nil || !hasSecretConfigParams(sys) {
+		return params
+	}
+
+	oldValues, err := parseSystemConfigParams(oldParams)
+	if err != nil {
+		return params
+	}
+
+	merged := false
+
+	for _, cp := range sys.ConfigParams {
+		if cp.Secret && values[cp.Name] == SecretMask {
+			if old, ok := oldValues[cp.Name]; ok {
+				values[cp.Name] = old
+				merged          = true
+			}
+		}
+	}
+
+	if !merged {
+		return params
+	}
+
+	return formatSystemConfigParams(values, params)
+}
+
+//=============================================================================
+
+func removeMaskedConfigParams(params string) string {
+	values, err := parseSystemConfigParams(params)
+	if err != nil {
+		return params
+	}
+
+	removed := false
+
+	for name, value := range values {
+		if value == SecretMask {
+			delete(values, name)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return params
+	}
+
+	return formatSystemConfigParams(values, params)
+}
+
+//=============================================================================
+
+func hasSecretConfigParams(sys *platform.System) bool {
+	return slices.ContainsFunc(sys.ConfigParams, func(cp platform.ConfigParam) bool { return cp.Secret })
+}
+
+//=============================================================================
+
+func isSecretConfigParam(sys *platform.System, name string, value any) bool {
+	if s, ok := value.(string); ok && vault.IsEncrypted(s) {
+		return true
+	}
+
+	return sys != nil && slices.ContainsFunc(sys.ConfigParams, func(cp platform.ConfigParam) bool { return cp.Name == name && cp.Secret })
+}
+
+//=============================================================================
+
+func parseSystemConfigParams(params string) (map[string]any, error) {
+	values := map[string]any{}
+
+	if strings.TrimSpace(params) == "" {
+		return values, nil
+	}
+
+	err := json.Unmarshal([]byte(params), &values)
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	return values, err
+}
+
+//=============================================================================
+
+func formatSystemConfigParams(values map[string]any, defValue string) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return defValue
+	}
+
+	return string(data)
+}
+
+//=============================================================================
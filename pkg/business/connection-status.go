@@ -0,0 +1,383 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/core/messaging/command"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+const (
+	DefaultStatusHistoryDays = 7
+	MaxStatusHistoryDays     = 365
+)
+
+//--- Time given to the system adapter to confirm a connection request
+
+const ConnectTimeout = 2 * time.Minute
+
+//=============================================================================
+
+type ConnectionStatusHistory struct {
+	ConnectionId uint                        `json:"connectionId"`
+	From         time.Time                   `json:"from"`
+	To           time.Time                   `json:"to"`
+	Uptime       float64                     `json:"uptime"`
+	Changes      []db.ConnectionStatusChange `json:"changes"`
+}
+
+//-----------------------------------------------------------------------------
+//--- Percentages of time the connection was connected
+
+type ConnectionUptime struct {
+	ConnectionId    uint       `json:"connectionId"`
+	Connected       bool       `json:"connected"`
+	LastConnectedAt *time.Time `json:"lastConnectedAt"`
+	LastDay         float64    `json:"lastDay"`
+	LastWeek        float64    `json:"lastWeek"`
+	LastMonth       float64    `json:"lastMonth"`
+}
+
+//=============================================================================
+
+func GetConnectionStatusHistory(tx *gorm.DB, c *auth.Context, id uint, days int) (*ConnectionStatusHistory, error) {
+	if days < 1 || days > MaxStatusHistoryDays {
+		return nil, req.NewBadRequestError("Days must be between 1 and 365: %v", days)
+	}
+
+	conn, err := getConnectionAndCheckAccess(tx, c, id, "GetConnectionStatusHistory")
+	if err != nil {
+		return nil, err
+	}
+
+	to   := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	list, err := db.GetConnectionStatusChanges(tx, id, from)
+	if err != nil {
+		c.Log.Error("GetConnectionStatusHistory: Could not retrieve status changes", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	uptime, err := computeUptime(tx, conn, from, to)
+	if err != nil {
+		c.Log.Error("GetConnectionStatusHistory: Could not compute uptime", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	return &ConnectionStatusHistory{
+		ConnectionId: id,
+		From        : from,
+		To          : to,
+		Uptime      : uptime,
+		Changes     : *list,
+	}, nil
+}
+
+//=============================================================================
+
+func GetConnectionUptime(tx *gorm.DB, c *auth.Context, id uint) (*ConnectionUptime, error) {
+	conn, err := getConnectionAndCheckAccess(tx, c, id, "GetConnectionUptime")
+	if err != nil {
+		return nil, err
+	}
+
+	cu := ConnectionUptime{
+		ConnectionId   : id,
+		Connected      : conn.Connected,
+		LastConnectedAt: conn.LastConnectedAt,
+	}
+
+	now := time.Now()
+
+	cu.LastDay, err = computeUptime(tx, conn, now.AddDate(0, 0, -1), now)
+	if err == nil {
+		cu.LastWeek, err = computeUptime(tx, conn, now.AddDate(0, 0, -7), now)
+	}
+	if err == nil {
+		cu.LastMonth, err = computeUptime(tx, conn, now.AddDate(0, 0, -30), now)
+	}
+
+	if err != nil {
+		c.Log.Error("GetConnectionUptime: Could not compute uptime", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	return &cu, nil
+}
+
+//=============================================================================
+
+func ConnectConnection(tx *gorm.DB, c *auth.Context, id uint) (*db.Connection, error) {
+	c.Log.Info("ConnectConnection: Requesting connection", "id", id)
+
+	conn, err := getConnectionAndCheckAccess(tx, c, id, "ConnectConnection")
+	if err != nil {
+		return nil, err
+	}
+
+	if conn.SupportsMultipleData {
+		return nil, req.NewUnprocessableEntityError("Connection is always connected: %v", id)
+	}
+
+	if conn.Connected {
+		return nil, req.NewUnprocessableEntityError("Connection is already connected: %v", id)
+	}
+
+	if conn.ConnectingAt != nil {
+		return nil, req.NewUnprocessableEntityError("Connection is already connecting: %v", id)
+	}
+
+	err = maskConnection(c, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ChangeConnectionStatus(tx, conn, db.ConnectionStatusConnecting, db.ConnectionSourceUser)
+	if err != nil {
+		c.Log.Error("ConnectConnection: Could not change connection status", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendConnectionCommandMessage(c, conn, msg.TypeActivate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("ConnectConnection: Connection requested", "id", id, "code", conn.Code)
+	return conn, nil
+}
+
+//=============================================================================
+//--- The status changes when the system adapter confirms the disconnection
+
+func DisconnectConnection(tx *gorm.DB, c *auth.Context, id uint) (*db.Connection, error) {
+	c.Log.Info("DisconnectConnection: Requesting disconnection", "id", id)
+
+	conn, err := getConnectionAndCheckAccess(tx, c, id, "DisconnectConnection")
+	if err != nil {
+		return nil, err
+	}
+
+	if conn.SupportsMultipleData {
+		return nil, req.NewUnprocessableEntityError("Connection is always connected: %v", id)
+	}
+
+	if !conn.Connected && conn.ConnectingAt == nil {
+		return nil, req.NewUnprocessableEntityError("Connection is not connected: %v", id)
+	}
+
+	err = maskConnection(c, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sendConnectionCommandMessage(c, conn, msg.TypeDeactivate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("DisconnectConnection: Disconnection requested", "id", id, "code", conn.Code)
+	return conn, nil
+}
+
+//=============================================================================
+//--- Called when the status changes. Changes to the same status are ignored
+
+func ChangeConnectionStatus(tx *gorm.DB, conn *db.Connection, status db.ConnectionStatus, source string) error {
+	now := time.Now()
+
+	if status == db.ConnectionStatusConnecting {
+		if conn.Connected || conn.ConnectingAt != nil {
+			return nil
+		}
+
+		conn.ConnectingAt = &now
+	} else {
+		connected := status == db.ConnectionStatusConnected
+		if conn.Connected == connected && conn.ConnectingAt == nil {
+			return nil
+		}
+
+		if connected {
+			conn.LastConnectedAt = &now
+		}
+
+		conn.Connected    = connected
+		conn.ConnectingAt = nil
+	}
+
+	err := db.SetConnectionStatus(tx, conn)
+	if err != nil {
+		return err
+	}
+
+	return db.AddConnectionStatusChange(tx, &db.ConnectionStatusChange{
+		ConnectionId: conn.Id,
+		Status      : status,
+		Source      : source,
+		CreatedAt   : now,
+	})
+}
+
+//=============================================================================
+//--- Connections not confirmed by the system adapter in time are disconnected
+
+func ExpireConnectingConnections(tx *gorm.DB) (int, error) {
+	list, err := db.GetConnectionsConnectingBefore(tx, time.Now().Add(-ConnectTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, conn := range *list {
+		err = ChangeConnectionStatus(tx, &conn, db.ConnectionStatusDisconnected, db.ConnectionSourceTimeout)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(*list), nil
+}
+
+//=============================================================================
+
+func DisconnectAllConnections(tx *gorm.DB, source string) error {
+	list, err := db.GetConnectionsToDisconnect(tx)
+	if err != nil {
+		return err
+	}
+
+	err = db.DisconnectAll(tx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, conn := range *list {
+		err = db.AddConnectionStatusChange(tx, &db.ConnectionStatusChange{
+			ConnectionId: conn.Id,
+			Status      : db.ConnectionStatusDisconnected,
+			Source      : source,
+			CreatedAt   : now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+//--- Commands go to the system adapter only, through its dedicated queue
+
+func sendConnectionCommandMessage(c *auth.Context, conn *db.Connection, msgType int) error {
+	ccm := ConnectionCommandMessage{
+		ConnectionId  : conn.Id,
+		Username      : conn.Username,
+		ConnectionCode: conn.Code,
+		SystemCode    : conn.SystemCode,
+	}
+
+	err := msg.SendMessage(command.ExInventoryCommand, msg.SourceConnection, msgType, &ccm)
+	if err != nil {
+		c.Log.Error("sendConnectionCommandMessage: Could not publish the command message", "id", conn.Id, "error", err.Error())
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Without changes before the window, the connection is considered connected
+//--- only if the first change is a disconnection. Without changes at all, the current status
+//--- is used. The window doesn't start before the connection was created
+
+func computeUptime(tx *gorm.DB, conn *db.Connection, from time.Time, to time.Time) (float64, error) {
+	if conn.CreatedAt.After(from) {
+		from = conn.CreatedAt
+	}
+
+	if !to.After(from) {
+		return 0, nil
+	}
+
+	list, err := db.GetConnectionStatusChanges(tx, conn.Id, from)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := db.GetLastConnectionStatusChangeBefore(tx, conn.Id, from)
+	if err != nil {
+		return 0, err
+	}
+
+	var connected bool
+
+	if last != nil {
+		connected = last.Status == db.ConnectionStatusConnected
+	} else if len(*list) > 0 {
+		connected = (*list)[0].Status == db.ConnectionStatusDisconnected
+	} else {
+		connected = conn.Connected
+	}
+
+	var upTime time.Duration
+	start := from
+
+	for _, csc := range *list {
+		if csc.CreatedAt.After(to) {
+			break
+		}
+
+		if connected {
+			upTime += csc.CreatedAt.Sub(start)
+		}
+
+		connected = csc.Status == db.ConnectionStatusConnected
+		start     = csc.CreatedAt
+	}
+
+	if connected {
+		upTime += to.Sub(start)
+	}
+
+	return float64(upTime) / float64(to.Sub(from)) * 100, nil
+}
+
+//=============================================================================
@@ -25,9 +25,12 @@ THE SOFTWARE.
 package business
 
 import (
+	"time"
+
 	"github.com/tradalia/core/auth"
 	"github.com/tradalia/core/msg"
 	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/core/vault"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"github.com/tradalia/inventory-server/pkg/platform"
 	"gorm.io/gorm"
@@ -35,12 +38,29 @@ import (
 
 //=============================================================================
 
-func GetConnections(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.Connection, error) {
+func GetConnections(tx *gorm.DB, c *auth.Context, q *db.ListQuery, offset int, limit int) (*[]db.Connection, int64, error) {
 	if ! c.Session.IsAdmin() {
-		filter["username"] = c.Session.Username
+		q.Filter["username"] = c.Session.Username
+	}
+
+	total, err := db.CountConnections(tx, q)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return db.GetConnections(tx, filter, offset, limit)
+	list, err := db.GetConnections(tx, q, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range *list {
+		err = maskConnection(c, &(*list)[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return list, total, nil
 }
 
 //=============================================================================
@@ -61,6 +81,11 @@ func GetConnectionById(tx *gorm.DB, c *auth.Context, id uint) (*db.Connection, e
 		}
 	}
 
+	err = maskConnection(c, conn)
+	if err != nil {
+		return nil, err
+	}
+
 	return conn, nil
 }
 
@@ -80,12 +105,24 @@ func AddConnection(tx *gorm.DB, c *auth.Context, cs *ConnectionSpec) (*db.Connec
 		return nil, req.NewNotFoundError("System not found: %v", cs.SystemCode)
 	}
 
+	err = validateSystemConfigParams(sys, cs.SystemConfigParams)
+	if err != nil {
+		c.Log.Info("AddConnection: Invalid system config params", "code", cs.Code, "error", err.Error())
+		return nil, err
+	}
+
+	params, err := encryptSystemConfigParams(sys, cs.SystemConfigParams)
+	if err != nil {
+		c.Log.Error("AddConnection: Could not encrypt system config params", "code", cs.Code, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
 	var conn db.Connection
 	conn.Username             = c.Session.Username
 	conn.Code                 = cs.Code
 	conn.Name                 = cs.Name
 	conn.SystemCode           = cs.SystemCode
-	conn.SystemConfigParams   = cs.SystemConfigParams
+	conn.SystemConfigParams   = params
 	conn.SystemName           = sys.Name
 	conn.SupportsData         = sys.SupportsData
 	conn.SupportsBroker       = sys.SupportsBroker
@@ -93,6 +130,11 @@ func AddConnection(tx *gorm.DB, c *auth.Context, cs *ConnectionSpec) (*db.Connec
 	conn.SupportsInventory    = sys.SupportsInventory
 	conn.Connected            = conn.SupportsMultipleData
 
+	if conn.Connected {
+		now := time.Now()
+		conn.LastConnectedAt = &now
+	}
+
 	err = db.AddConnection(tx, &conn)
 
 	if err != nil {
@@ -101,7 +143,8 @@ func AddConnection(tx *gorm.DB, c *auth.Context, cs *ConnectionSpec) (*db.Connec
 	}
 
 	c.Log.Info("AddConnection: Connection added", "code", cs.Code, "id", conn.Id)
-	return &conn, err
+	conn.SystemConfigParams = maskSystemConfigParams(sys, conn.SystemConfigParams)
+	return &conn, nil
 }
 
 //=============================================================================
@@ -114,8 +157,32 @@ func UpdateConnection(tx *gorm.DB, c *auth.Context, id uint, cs *ConnectionSpec)
 		return nil, err
 	}
 
+	sys, err := platform.GetSystem(c, conn.SystemCode)
+	if err != nil {
+		c.Log.Info("UpdateConnection: Unable to retrieve the system", "code", conn.SystemCode)
+		return nil, err
+	}
+
+	params := cs.SystemConfigParams
+
+	if sys != nil {
+		params = mergeSystemConfigParams(sys, params, conn.SystemConfigParams)
+
+		err = validateSystemConfigParams(sys, params)
+		if err != nil {
+			c.Log.Info("UpdateConnection: Invalid system config params", "id", id, "error", err.Error())
+			return nil, err
+		}
+
+		params, err = encryptSystemConfigParams(sys, params)
+		if err != nil {
+			c.Log.Error("UpdateConnection: Could not encrypt system config params", "id", id, "error", err.Error())
+			return nil, req.NewServerErrorByError(err)
+		}
+	}
+
 	conn.Name                = cs.Name
-	conn.SystemConfigParams  = cs.SystemConfigParams
+	conn.SystemConfigParams  = params
 
 	err = db.UpdateConnection(tx, conn)
 	if err != nil {
@@ -123,7 +190,96 @@ func UpdateConnection(tx *gorm.DB, c *auth.Context, id uint, cs *ConnectionSpec)
 	}
 
 	c.Log.Info("UpdateConnection: Connection updated", "id", conn.Id, "name", conn.Name)
-	return conn, err
+	conn.SystemConfigParams = maskSystemConfigParams(sys, conn.SystemConfigParams)
+	return conn, nil
+}
+
+//=============================================================================
+//--- Returns the connection with decrypted secrets. Reserved to the system
+//--- adapter, which acts for all users
+
+func GetConnectionConfig(tx *gorm.DB, c *auth.Context, id uint) (*db.Connection, error) {
+	conn, err := db.GetConnectionById(tx, id)
+	if err != nil {
+		c.Log.Error("GetConnectionConfig: Could not retrieve connection", "error", err.Error())
+		return nil, err
+	}
+
+	if conn == nil {
+		return nil, req.NewNotFoundError("Connection was not found: %v", id)
+	}
+
+	conn.SystemConfigParams, err = DecryptSystemConfigParams(conn.SystemConfigParams)
+	if err != nil {
+		c.Log.Error("GetConnectionConfig: Could not decrypt system config params", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	return conn, nil
+}
+
+//=============================================================================
+//--- Encrypts secrets still in plain text and re-encrypts the ones using an old
+//--- master key. To be run after a key rotation
+
+func ReencryptConnections(tx *gorm.DB, c *auth.Context) (*ReencryptionResult, error) {
+	c.Log.Info("ReencryptConnections: Re-encrypting system config params")
+
+	if !vault.IsEnabled() {
+		return nil, req.NewBadRequestError("Encryption is not enabled: %v", "no active key")
+	}
+
+	q := db.NewListQuery(&db.ConnectionQueryFields)
+
+	list, err := db.GetConnections(tx, q, 0, -1)
+	if err != nil {
+		c.Log.Error("ReencryptConnections: Could not retrieve connections", "error", err.Error())
+		return nil, err
+	}
+
+	res := &ReencryptionResult{}
+
+	for _, conn := range *list {
+		res.Connections++
+
+		sys, err := platform.GetSystem(c, conn.SystemCode)
+		if err != nil {
+			return nil, err
+		}
+
+		params := conn.SystemConfigParams
+
+		if sys != nil {
+			params, err = encryptSystemConfigParams(sys, params)
+			if err != nil {
+				c.Log.Error("ReencryptConnections: Could not encrypt system config params", "id", conn.Id, "error", err.Error())
+				return nil, req.NewServerErrorByError(err)
+			}
+		}
+
+		params, _, err = rotateSystemConfigParams(params)
+		if err != nil {
+			c.Log.Error("ReencryptConnections: Could not rotate system config params", "id", conn.Id, "error", err.Error())
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		if params == conn.SystemConfigParams {
+			continue
+		}
+
+		conn.SystemConfigParams = params
+
+		err = db.UpdateConnection(tx, &conn)
+		if err != nil {
+			c.Log.Error("ReencryptConnections: Could not update connection", "id", conn.Id, "error", err.Error())
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		res.Updated++
+	}
+
+	c.Log.Info("ReencryptConnections: System config params re-encrypted", "connections", res.Connections, "updated", res.Updated)
+	return res, nil
 }
 
 //=============================================================================
@@ -163,6 +319,18 @@ func DeleteConnection(tx *gorm.DB, c *auth.Context, id uint) (*db.Connection, er
 //===
 //=============================================================================
 
+func maskConnection(c *auth.Context, conn *db.Connection) error {
+	sys, err := platform.GetSystem(c, conn.SystemCode)
+	if err != nil {
+		return err
+	}
+
+	conn.SystemConfigParams = maskSystemConfigParams(sys, conn.SystemConfigParams)
+	return nil
+}
+
+//=============================================================================
+
 func getConnectionAndCheckAccess(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.Connection, error) {
 	conn, err := db.GetConnectionById(tx, id)
 
@@ -25,6 +25,11 @@ THE SOFTWARE.
 package business
 
 import (
+	"errors"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
@@ -36,3 +41,252 @@ func GetCurrencies(tx *gorm.DB) (*[]db.Currency, error) {
 }
 
 //=============================================================================
+
+func GetCurrencyHistory(tx *gorm.DB, c *auth.Context, id uint, from datatype.IntDate, to datatype.IntDate, before datatype.IntDate, limit int) (*[]db.CurrencyHistory, int64, error) {
+	cu, err := db.GetCurrencyById(tx, id)
+	if err != nil {
+		c.Log.Error("GetCurrencyHistory: Could not retrieve currency", "error", err.Error())
+		return nil, 0, err
+	}
+
+	if cu == nil {
+		c.Log.Error("GetCurrencyHistory: Currency was not found", "id", id)
+		return nil, 0, req.NewNotFoundError("Currency was not found: %v", id)
+	}
+
+	total, err := db.CountCurrencyHistory(tx, id, from, to)
+	if err != nil {
+		c.Log.Error("GetCurrencyHistory: Could not count currency history", "error", err.Error(), "id", id)
+		return nil, 0, err
+	}
+
+	list, err := db.GetCurrencyHistory(tx, id, from, to, before, limit)
+	if err != nil {
+		c.Log.Error("GetCurrencyHistory: Could not retrieve currency history", "error", err.Error(), "id", id)
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+//=============================================================================
+//--- Users without a preference get values reported in the base currency
+
+func GetReportingCurrency(tx *gorm.DB, username string) (*db.Currency, error) {
+	up, err := db.GetUserPreferences(tx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if up != nil && up.ReportingCurrencyId != nil {
+		cu, err := db.GetCurrencyById(tx, *up.ReportingCurrencyId)
+		if err != nil {
+			return nil, err
+		}
+
+		if cu != nil {
+			return cu, nil
+		}
+	}
+
+	cu, err := db.GetCurrencyByCode(tx, db.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	if cu == nil {
+		return nil, errors.New("base currency not found: "+ db.BaseCurrency)
+	}
+
+	return cu, nil
+}
+
+//=============================================================================
+//===
+//=== Currency reviews
+//===
+//=============================================================================
+
+func GetCurrencyReviews(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.CurrencyReview, int64, error) {
+	total, err := db.CountCurrencyReviews(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := db.GetCurrencyReviews(tx, filter, offset, limit)
+	return list, total, err
+}
+
+//=============================================================================
+
+func AcceptCurrencyReview(tx *gorm.DB, c *auth.Context, id uint) (*db.CurrencyReview, error) {
+	c.Log.Info("AcceptCurrencyReview: Accepting currency value", "id", id)
+
+	cr, err := getPendingCurrencyReview(tx, c, id, "AcceptCurrencyReview")
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveCurrencyReview(tx, c, cr, cr.Value, db.CurrencyReviewAccepted, "AcceptCurrencyReview")
+}
+
+//=============================================================================
+
+func OverrideCurrencyReview(tx *gorm.DB, c *auth.Context, id uint, cros *CurrencyReviewOverrideSpec) (*db.CurrencyReview, error) {
+	c.Log.Info("OverrideCurrencyReview: Overriding currency value", "id", id, "value", cros.Value)
+
+	cr, err := getPendingCurrencyReview(tx, c, id, "OverrideCurrencyReview")
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveCurrencyReview(tx, c, cr, cros.Value, db.CurrencyReviewOverridden, "OverrideCurrencyReview")
+}
+
+//=============================================================================
+//===
+//=== Currency converter
+//===
+//=============================================================================
+
+type CurrencyConverter struct {
+	tx    *gorm.DB
+	rates map[rateKey]float64
+}
+
+//-----------------------------------------------------------------------------
+
+type rateKey struct {
+	currencyId uint
+	date       datatype.IntDate
+}
+
+//=============================================================================
+
+func NewCurrencyConverter(tx *gorm.DB) *CurrencyConverter {
+	return &CurrencyConverter{
+		tx   : tx,
+		rates: map[rateKey]float64{},
+	}
+}
+
+//=============================================================================
+
+func (cc *CurrencyConverter) Convert(amount float64, from, to *db.Currency, date datatype.IntDate) (float64, error) {
+	if from.Id == to.Id {
+		return amount, nil
+	}
+
+	fromRate, err := cc.getRate(from, date)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := cc.getRate(to, date)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount / fromRate * toRate, nil
+}
+
+//=============================================================================
+
+func (cc *CurrencyConverter) getRate(cur *db.Currency, date datatype.IntDate) (float64, error) {
+	if cur.Code == db.BaseCurrency {
+		return 1, nil
+	}
+
+	key := rateKey{ cur.Id, date }
+	if rate, ok := cc.rates[key]; ok {
+		return rate, nil
+	}
+
+	ch, err := db.GetCurrencyHistoryAt(cc.tx, cur.Id, date)
+	if err != nil {
+		return 0, err
+	}
+
+	if ch == nil || ch.Value == 0 {
+		return 0, errors.New("no exchange rate available for currency "+ cur.Code +" at "+ date.String())
+	}
+
+	cc.rates[key] = ch.Value
+	return ch.Value, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getPendingCurrencyReview(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.CurrencyReview, error) {
+	cr, err := db.GetCurrencyReviewById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve currency review", "error", err.Error())
+		return nil, err
+	}
+
+	if cr == nil {
+		c.Log.Error(function +": Currency review was not found", "id", id)
+		return nil, req.NewNotFoundError("Currency review was not found: %v", id)
+	}
+
+	if cr.Status != db.CurrencyReviewPending {
+		c.Log.Error(function +": Currency review already resolved", "id", id, "status", cr.Status)
+		return nil, req.NewUnprocessableEntityError("Currency review already resolved: %v", id)
+	}
+
+	return cr, nil
+}
+
+//=============================================================================
+//--- Publishes the value into the history, just like the updater would have done
+
+func resolveCurrencyReview(tx *gorm.DB, c *auth.Context, cr *db.CurrencyReview, value float64, status db.CurrencyReviewStatus, function string) (*db.CurrencyReview, error) {
+	cu, err := db.GetCurrencyById(tx, cr.CurrencyId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve currency", "error", err.Error(), "id", cr.Id)
+		return nil, err
+	}
+
+	if cu == nil {
+		c.Log.Error(function +": Currency was not found", "currencyId", cr.CurrencyId)
+		return nil, req.NewNotFoundError("Currency was not found: %v", cr.CurrencyId)
+	}
+
+	err = db.AddCurrencyHistory(tx, &db.CurrencyHistory{
+		CurrencyId: cr.CurrencyId,
+		Date      : cr.Date,
+		Value     : value,
+	})
+	if err != nil {
+		c.Log.Error(function +": Could not add currency history", "error", err.Error(), "id", cr.Id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	if cr.Date >= cu.LastDate {
+		cu.LastValue = value
+		err = db.UpdateCurrency(tx, cu)
+		if err != nil {
+			c.Log.Error(function +": Could not update currency", "error", err.Error(), "id", cr.Id)
+			return nil, req.NewServerErrorByError(err)
+		}
+	}
+
+	cr.Status     = status
+	cr.FinalValue = value
+	cr.ReviewedBy = c.Session.Username
+
+	err = db.UpdateCurrencyReview(tx, cr)
+	if err != nil {
+		c.Log.Error(function +": Could not update currency review", "error", err.Error(), "id", cr.Id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info(function +": Currency review resolved", "id", cr.Id, "currency", cu.Code, "date", cr.Date, "value", value)
+	return cr, nil
+}
+
+//=============================================================================
@@ -34,16 +34,25 @@ import (
 
 //=============================================================================
 
-func GetDataProducts(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int, details bool) (*[]db.DataProductFull, error) {
+func GetDataProducts(tx *gorm.DB, c *auth.Context, q *db.ListQuery, offset int, limit int, details bool) (*[]db.DataProductFull, int64, error) {
 	if ! c.Session.IsAdmin() {
-		filter["username"] = c.Session.Username
+		q.Filter["username"] = c.Session.Username
 	}
 
+	total, err := db.CountDataProducts(tx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list *[]db.DataProductFull
+
 	if details {
-		return db.GetDataProductsFull(tx, filter, offset, limit)
+		list, err = db.GetDataProductsFull(tx, q, offset, limit)
+	} else {
+		list, err = db.GetDataProducts(tx, q, offset, limit)
 	}
 
-	return db.GetDataProducts(tx, filter, offset, limit)
+	return list, total, err
 }
 
 //=============================================================================
@@ -64,6 +73,11 @@ func GetDataProductById(tx *gorm.DB, c *auth.Context, id uint) (*DataProductExt,
 		return nil, err
 	}
 
+	err = maskConnection(c, conn)
+	if err != nil {
+		return nil, err
+	}
+
 	//--- Get exchange
 
 	exc, err  := db.GetExchangeById(tx, pd.ExchangeId)
@@ -86,20 +100,26 @@ func GetDataProductById(tx *gorm.DB, c *auth.Context, id uint) (*DataProductExt,
 func AddDataProduct(tx *gorm.DB, c *auth.Context, pds *DataProductSpec) (*db.DataProduct, error) {
 	c.Log.Info("AddDataProduct: Adding a new data product", "symbol", pds.Symbol, "name", pds.Name)
 
+	err := checkCatalogProduct(tx, c, pds.CatalogProductId, "AddDataProduct")
+	if err != nil {
+		return nil, err
+	}
+
 	var pd db.DataProduct
-	pd.ConnectionId    = pds.ConnectionId
-	pd.ExchangeId      = pds.ExchangeId
-	pd.Username        = c.Session.Username
-	pd.Symbol          = pds.Symbol
-	pd.Name            = pds.Name
-	pd.MarketType      = pds.MarketType
-	pd.ProductType     = pds.ProductType
-	pd.Months          = pds.Months
-	pd.RolloverTrigger = pds.RolloverTrigger
+	pd.ConnectionId     = pds.ConnectionId
+	pd.ExchangeId       = pds.ExchangeId
+	pd.Username         = c.Session.Username
+	pd.Symbol           = pds.Symbol
+	pd.Name             = pds.Name
+	pd.MarketType       = pds.MarketType
+	pd.ProductType      = pds.ProductType
+	pd.Months           = pds.Months
+	pd.RolloverTrigger  = pds.RolloverTrigger
+	pd.CatalogProductId = pds.CatalogProductId
 
 	//TODO: validate rollover trigger
 
-	err := db.AddDataProduct(tx, &pd)
+	err = db.AddDataProduct(tx, &pd)
 
 	if err != nil {
 		c.Log.Error("AddDataProduct: Could not add a new data product", "error", err.Error())
@@ -125,12 +145,23 @@ func UpdateDataProduct(tx *gorm.DB, c *auth.Context, id uint, pds *DataProductSp
 		return nil, err
 	}
 
+	err = checkCatalogProduct(tx, c, pds.CatalogProductId, "UpdateDataProduct")
+	if err != nil {
+		return nil, err
+	}
+
 	//--- We can't change the exchange and the symbol
 
 	pd.Name        = pds.Name
 	pd.MarketType  = pds.MarketType
 	pd.ProductType = pds.ProductType
 
+	//--- A missing catalog product keeps the current link
+
+	if pds.CatalogProductId != nil {
+		pd.CatalogProductId = pds.CatalogProductId
+	}
+
 	//TODO: Should we allow to modify these? Some recomputation is required
 	//pd.Months          = pds.Months
 	//pd.RolloverTrigger = pds.RolloverTrigger
@@ -193,6 +224,8 @@ func sendDataProductChangeMessage(tx *gorm.DB, c *auth.Context, pd *db.DataProdu
 		return err
 	}
 
+	conn.SystemConfigParams = redactSystemConfigParams(conn.SystemConfigParams)
+
 	pdm := DataProductMessage{*pd, *conn, *exc}
 	err = msg.SendMessage(msg.ExInventory, msg.SourceDataProduct, msgType, &pdm)
 
@@ -0,0 +1,439 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+
+package business
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+const (
+	CalendarFormatCsv = "csv"
+	CalendarFormatIcs = "ics"
+
+	MaxCalendarDays = 1000
+)
+
+//=============================================================================
+
+type ExchangeHoursSpec struct {
+	Weekday   int `json:"weekday"   binding:"min=0,max=6"`
+	OpenTime  int `json:"openTime"  binding:"min=0,max=2359"`
+	CloseTime int `json:"closeTime" binding:"min=0,max=2359"`
+}
+
+//=============================================================================
+
+type CalendarDay struct {
+	Date       datatype.IntDate `json:"date"`
+	Trading    bool             `json:"trading"`
+	EarlyClose bool             `json:"earlyClose"`
+	OpenTime   int              `json:"openTime"`
+	CloseTime  int              `json:"closeTime"`
+	Holiday    string           `json:"holiday,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type ExchangeCalendar struct {
+	Exchange *db.Exchange     `json:"exchange"`
+	From     datatype.IntDate `json:"from"`
+	To       datatype.IntDate `json:"to"`
+	Days     []CalendarDay    `json:"days"`
+}
+
+//=============================================================================
+
+type CalendarImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+//=============================================================================
+
+func GetExchangeCalendar(tx *gorm.DB, c *auth.Context, id uint, from, to datatype.IntDate) (*ExchangeCalendar, error) {
+	c.Log.Info("GetExchangeCalendar: Getting exchange calendar", "id", id, "from", from, "to", to)
+
+	if from.IsNil() || to.IsNil() || from > to {
+		return nil, req.NewBadRequestError("Invalid date range: %v", from.String() +" -> "+ to.String())
+	}
+
+	if daysBetween(from, to) > MaxCalendarDays {
+		return nil, req.NewBadRequestError("Date range too large. Max days: %v", MaxCalendarDays)
+	}
+
+	ex, err := getExchange(tx, c, id, "GetExchangeCalendar")
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := buildCalendar(tx, id, from, to)
+	if err != nil {
+		c.Log.Error("GetExchangeCalendar: Could not build the calendar", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	return &ExchangeCalendar{
+		Exchange: ex,
+		From    : from,
+		To      : to,
+		Days    : days,
+	}, nil
+}
+
+//=============================================================================
+
+func SetExchangeHours(tx *gorm.DB, c *auth.Context, id uint, specs *[]ExchangeHoursSpec) (*[]db.ExchangeHours, error) {
+	c.Log.Info("SetExchangeHours: Setting trading hours", "id", id)
+
+	_, err := getExchange(tx, c, id, "SetExchangeHours")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []db.ExchangeHours
+	weekdays := map[int]bool{}
+
+	for _, spec := range *specs {
+		if weekdays[spec.Weekday] {
+			c.Log.Error("SetExchangeHours: Duplicated weekday", "id", id, "weekday", spec.Weekday)
+			return nil, req.NewBadRequestError("Duplicated weekday: %v", spec.Weekday)
+		}
+
+		if !isValidTime(spec.OpenTime) || !isValidTime(spec.CloseTime) {
+			c.Log.Error("SetExchangeHours: Invalid trading hours", "id", id, "weekday", spec.Weekday)
+			return nil, req.NewBadRequestError("Invalid trading hours for weekday: %v", spec.Weekday)
+		}
+
+		weekdays[spec.Weekday] = true
+
+		list = append(list, db.ExchangeHours{
+			ExchangeId: id,
+			Weekday   : spec.Weekday,
+			OpenTime  : spec.OpenTime,
+			CloseTime : spec.CloseTime,
+		})
+	}
+
+	err = db.SetExchangeHours(tx, id, &list)
+	if err != nil {
+		c.Log.Error("SetExchangeHours: Could not save trading hours", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info("SetExchangeHours: Trading hours set", "id", id, "days", len(list))
+	return &list, nil
+}
+
+//=============================================================================
+
+func ImportExchangeHolidays(tx *gorm.DB, c *auth.Context, id uint, format string, data []byte) (*CalendarImportResponse, error) {
+	c.Log.Info("ImportExchangeHolidays: Importing holidays", "id", id, "format", format)
+
+	_, err := getExchange(tx, c, id, "ImportExchangeHolidays")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*db.ExchangeHoliday
+
+	switch format {
+	case CalendarFormatCsv:
+		list, err = parseHolidaysCsv(data)
+	case CalendarFormatIcs:
+		list, err = parseHolidaysIcs(data)
+	default:
+		return nil, req.NewBadRequestError("Unknown calendar format: %v", format)
+	}
+
+	if err != nil {
+		c.Log.Error("ImportExchangeHolidays: Could not parse calendar file", "error", err.Error(), "id", id)
+		return nil, req.NewBadRequestError("Invalid calendar file: %v", err.Error())
+	}
+
+	for _, h := range list {
+		h.ExchangeId = id
+		err = db.SetExchangeHoliday(tx, h)
+		if err != nil {
+			c.Log.Error("ImportExchangeHolidays: Could not save holiday", "error", err.Error(), "id", id, "date", h.Date)
+			return nil, req.NewServerErrorByError(err)
+		}
+	}
+
+	c.Log.Info("ImportExchangeHolidays: Holidays imported", "id", id, "count", len(list))
+	return &CalendarImportResponse{
+		Imported: len(list),
+	}, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func buildCalendar(tx *gorm.DB, exchangeId uint, from, to datatype.IntDate) ([]CalendarDay, error) {
+	hours, err := db.GetExchangeHours(tx, exchangeId)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := db.GetExchangeHolidays(tx, exchangeId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	hoursMap := map[int]db.ExchangeHours{}
+	for _, h := range *hours {
+		hoursMap[h.Weekday] = h
+	}
+
+	holidayMap := map[datatype.IntDate]db.ExchangeHoliday{}
+	for _, h := range *holidays {
+		holidayMap[h.Date] = h
+	}
+
+	var days []CalendarDay
+
+	for date := from; date <= to; date = date.AddDays(1) {
+		weekday := int(date.ToDateTime(false, time.UTC).Weekday())
+		day     := CalendarDay{ Date: date }
+
+		//--- Without configured hours, we assume a Monday to Friday week
+
+		if len(hoursMap) == 0 {
+			day.Trading = weekday != int(time.Saturday) && weekday != int(time.Sunday)
+		} else if h, ok := hoursMap[weekday]; ok {
+			day.Trading   = true
+			day.OpenTime  = h.OpenTime
+			day.CloseTime = h.CloseTime
+		}
+
+		if h, ok := holidayMap[date]; ok && day.Trading {
+			day.Holiday = h.Name
+
+			if h.Type == db.HolidayTypeEarlyClose {
+				day.EarlyClose = true
+				day.CloseTime  = h.CloseTime
+			} else {
+				day.Trading   = false
+				day.OpenTime  = 0
+				day.CloseTime = 0
+			}
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+//=============================================================================
+//--- Expected columns: date (yyyymmdd or yyyy-mm-dd), name, closeTime (optional).
+//--- A non-zero close time marks the day as an early close
+
+func parseHolidaysCsv(data []byte) ([]*db.ExchangeHoliday, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var list []*db.ExchangeHoliday
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 2 {
+			return nil, errors.New("line "+ strconv.Itoa(line) +": expected at least date and name")
+		}
+
+		date, err := parseCalendarDate(record[0])
+		if err != nil {
+			//--- Skip the header, if any
+			if line == 1 {
+				continue
+			}
+
+			return nil, errors.New("line "+ strconv.Itoa(line) +": "+ err.Error())
+		}
+
+		h := &db.ExchangeHoliday{
+			Date: date,
+			Name: strings.TrimSpace(record[1]),
+			Type: db.HolidayTypeClosed,
+		}
+
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			closeTime, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(record[2]), ":", ""))
+			if err != nil || !isValidTime(closeTime) {
+				return nil, errors.New("line "+ strconv.Itoa(line) +": invalid close time")
+			}
+
+			if closeTime != 0 {
+				h.Type      = db.HolidayTypeEarlyClose
+				h.CloseTime = closeTime
+			}
+		}
+
+		list = append(list, h)
+	}
+
+	return list, nil
+}
+
+//=============================================================================
+//--- Every VEVENT is a full closing day. All-day events spanning several days
+//--- have an exclusive DTEND, as per RFC 5545
+
+func parseHolidaysIcs(data []byte) ([]*db.ExchangeHoliday, error) {
+	var list []*db.ExchangeHoliday
+	var start, end datatype.IntDate
+	var summary string
+
+	inEvent := false
+
+	for _, line := range unfoldIcsLines(data) {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		//--- Strip parameters like DTSTART;VALUE=DATE
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch name {
+		case "BEGIN":
+			if value == "VEVENT" {
+				inEvent = true
+				start, end, summary = 0, 0, ""
+			}
+
+		case "DTSTART", "DTEND":
+			if inEvent {
+				if len(value) < 8 {
+					return nil, errors.New("invalid date: "+ value)
+				}
+
+				date, err := parseCalendarDate(value[0:8])
+				if err != nil {
+					return nil, err
+				}
+
+				if name == "DTSTART" {
+					start = date
+				} else {
+					end = date
+				}
+			}
+
+		case "SUMMARY":
+			if inEvent {
+				summary = value
+			}
+
+		case "END":
+			if value == "VEVENT" && inEvent {
+				inEvent = false
+
+				if start.IsNil() {
+					return nil, errors.New("event without DTSTART: "+ summary)
+				}
+
+				if end <= start {
+					end = start.AddDays(1)
+				}
+
+				for date := start; date < end; date = date.AddDays(1) {
+					list = append(list, &db.ExchangeHoliday{
+						Date: date,
+						Name: summary,
+						Type: db.HolidayTypeClosed,
+					})
+				}
+			}
+		}
+	}
+
+	return list, nil
+}
+
+//=============================================================================
+
+func unfoldIcsLines(data []byte) []string {
+	var lines []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+//=============================================================================
+
+func parseCalendarDate(value string) (datatype.IntDate, error) {
+	return datatype.ParseIntDate(strings.ReplaceAll(strings.TrimSpace(value), "-", ""), true)
+}
+
+//=============================================================================
+
+func isValidTime(t int) bool {
+	return t >= 0 && t/100 < 24 && t%100 < 60
+}
+
+//=============================================================================
+
+func daysBetween(from, to datatype.IntDate) int {
+	return int(to.ToDateTime(false, time.UTC).Sub(from.ToDateTime(false, time.UTC)).Hours() / 24)
+}
+
+//=============================================================================
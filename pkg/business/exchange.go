@@ -25,6 +25,11 @@ THE SOFTWARE.
 package business
 
 import (
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
@@ -36,3 +41,257 @@ func GetExchanges(tx *gorm.DB) (*[]db.Exchange, error) {
 }
 
 //=============================================================================
+
+func GetExchangeById(tx *gorm.DB, c *auth.Context, id uint) (*db.Exchange, error) {
+	return getExchange(tx, c, id, "GetExchangeById")
+}
+
+//=============================================================================
+
+func AddExchange(tx *gorm.DB, c *auth.Context, es *ExchangeSpec) (*db.Exchange, error) {
+	c.Log.Info("AddExchange: Adding a new exchange", "code", es.Code, "name", es.Name)
+
+	err := validateExchangeSpec(tx, c, 0, es, "AddExchange")
+	if err != nil {
+		return nil, err
+	}
+
+	var ex db.Exchange
+	ex.CurrencyId = es.CurrencyId
+	ex.Code       = es.Code
+	ex.Name       = es.Name
+	ex.Timezone   = es.Timezone
+	ex.Url        = es.Url
+
+	err = db.AddExchange(tx, &ex)
+	if err != nil {
+		c.Log.Error("AddExchange: Could not add a new exchange", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendExchangeChangeMessage(tx, c, &ex, msg.TypeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("AddExchange: Exchange added", "code", ex.Code, "id", ex.Id)
+	return &ex, nil
+}
+
+//=============================================================================
+
+func UpdateExchange(tx *gorm.DB, c *auth.Context, id uint, es *ExchangeSpec) (*db.Exchange, error) {
+	c.Log.Info("UpdateExchange: Updating an exchange", "id", id, "name", es.Name)
+
+	ex, err := getExchange(tx, c, id, "UpdateExchange")
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateExchangeSpec(tx, c, id, es, "UpdateExchange")
+	if err != nil {
+		return nil, err
+	}
+
+	ex.CurrencyId = es.CurrencyId
+	ex.Code       = es.Code
+	ex.Name       = es.Name
+	ex.Timezone   = es.Timezone
+	ex.Url        = es.Url
+
+	err = db.UpdateExchange(tx, ex)
+	if err != nil {
+		c.Log.Error("UpdateExchange: Could not update the exchange", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendExchangeChangeMessage(tx, c, ex, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	err = refreshExchangeDependents(tx, c, ex)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("UpdateExchange: Exchange updated", "id", ex.Id, "code", ex.Code)
+	return ex, nil
+}
+
+//=============================================================================
+
+func DeleteExchange(tx *gorm.DB, c *auth.Context, id uint) (*db.Exchange, error) {
+	c.Log.Info("DeleteExchange: Deleting exchange", "id", id)
+
+	ex, err := getExchange(tx, c, id, "DeleteExchange")
+	if err != nil {
+		return nil, err
+	}
+
+	bps, err := db.GetBrokerProductsByExchangeId(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteExchange: Could not retrieve broker products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	dps, err := db.GetDataProductsByExchangeId(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteExchange: Could not retrieve data products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if len(*bps) > 0 || len(*dps) > 0 {
+		c.Log.Error("DeleteExchange: Exchange is still referenced by products", "id", id, "brokerProducts", len(*bps), "dataProducts", len(*dps))
+		return nil, req.NewUnprocessableEntityError("Exchange is still referenced by products: %v", id)
+	}
+
+	err = db.DeleteExchangeCalendar(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteExchange: Cannot delete exchange calendar", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = db.DeleteExchange(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteExchange: Cannot delete exchange", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendExchangeChangeMessage(tx, c, ex, msg.TypeDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("DeleteExchange: Exchange deleted", "id", id, "code", ex.Code)
+	return ex, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getExchange(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.Exchange, error) {
+	ex, err := db.GetExchangeById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve exchange", "error", err.Error())
+		return nil, err
+	}
+
+	if ex == nil {
+		c.Log.Error(function +": Exchange was not found", "id", id)
+		return nil, req.NewNotFoundError("Exchange was not found: %v", id)
+	}
+
+	return ex, nil
+}
+
+//=============================================================================
+
+func validateExchangeSpec(tx *gorm.DB, c *auth.Context, id uint, es *ExchangeSpec, function string) error {
+	if es.Timezone != "utc" {
+		if _, err := time.LoadLocation(es.Timezone); err != nil {
+			c.Log.Error(function +": Invalid timezone", "timezone", es.Timezone, "error", err.Error())
+			return req.NewBadRequestError("Invalid timezone: %v", es.Timezone)
+		}
+	}
+
+	cu, err := db.GetCurrencyById(tx, es.CurrencyId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve currency", "error", err.Error())
+		return err
+	}
+
+	if cu == nil {
+		c.Log.Error(function +": Currency was not found", "currencyId", es.CurrencyId)
+		return req.NewNotFoundError("Currency was not found: %v", es.CurrencyId)
+	}
+
+	ex, err := db.GetExchangeByCode(tx, es.Code)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve exchange by code", "error", err.Error())
+		return err
+	}
+
+	if ex != nil && ex.Id != id {
+		c.Log.Error(function +": Exchange code already in use", "code", es.Code)
+		return req.NewBadRequestError("Exchange code already in use: %v", es.Code)
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func sendExchangeChangeMessage(tx *gorm.DB, c *auth.Context, ex *db.Exchange, msgType int) error {
+	cu, err := db.GetCurrencyById(tx, ex.CurrencyId)
+	if err != nil {
+		c.Log.Error("sendExchangeChangeMessage: Could not retrieve currency", "error", err.Error(), "id", ex.Id)
+		return err
+	}
+
+	exm := ExchangeMessage{ Exchange: *ex }
+	if cu != nil {
+		exm.Currency = *cu
+	}
+
+	err = msg.SendMessage(msg.ExInventory, SourceExchange, msgType, &exm)
+	if err != nil {
+		c.Log.Error("sendExchangeChangeMessage: Could not publish the change message", "error", err.Error(), "id", ex.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Products and trading systems carry a copy of the exchange in their
+//--- messages, so we re-publish them to let consumers refresh their data
+
+func refreshExchangeDependents(tx *gorm.DB, c *auth.Context, ex *db.Exchange) error {
+	bps, err := db.GetBrokerProductsByExchangeId(tx, ex.Id)
+	if err != nil {
+		c.Log.Error("refreshExchangeDependents: Could not retrieve broker products", "error", err.Error(), "id", ex.Id)
+		return err
+	}
+
+	for _, bp := range *bps {
+		err = sendBrokerProductChangeMessage(tx, c, &bp, msg.TypeUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	dps, err := db.GetDataProductsByExchangeId(tx, ex.Id)
+	if err != nil {
+		c.Log.Error("refreshExchangeDependents: Could not retrieve data products", "error", err.Error(), "id", ex.Id)
+		return err
+	}
+
+	for _, dp := range *dps {
+		err = sendDataProductChangeMessage(tx, c, &dp, msg.TypeUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	tss, err := db.GetTradingSystemsByExchangeId(tx, ex.Id)
+	if err != nil {
+		c.Log.Error("refreshExchangeDependents: Could not retrieve trading systems", "error", err.Error(), "id", ex.Id)
+		return err
+	}
+
+	for _, ts := range *tss {
+		err = sendChangeMessage(tx, c, &ts, msg.TypeUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Log.Info("refreshExchangeDependents: Dependents refreshed", "id", ex.Id, "brokerProducts", len(*bps), "dataProducts", len(*dps), "tradingSystems", len(*tss))
+	return nil
+}
+
+//=============================================================================
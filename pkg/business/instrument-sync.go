@@ -0,0 +1,508 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"github.com/tradalia/inventory-server/pkg/platform"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Connections supporting the inventory can list the instruments available
+//=== on the remote system. Instruments can be used to create products and to
+//=== check existing products against the remote definitions
+//===
+//=============================================================================
+
+type RemoteInstrument struct {
+	platform.Instrument
+	ExchangeId      uint  `json:"exchangeId,omitempty"`
+	DataProductId   *uint `json:"dataProductId,omitempty"`
+	BrokerProductId *uint `json:"brokerProductId,omitempty"`
+}
+
+//=============================================================================
+
+const (
+	DriftProductData   = "data"
+	DriftProductBroker = "broker"
+)
+
+const (
+	DriftFieldMissing    = "missing"
+	DriftFieldExchange   = "exchange"
+	DriftFieldPointValue = "pointValue"
+	DriftFieldIncrement  = "increment"
+)
+
+//-----------------------------------------------------------------------------
+
+type ProductDrift struct {
+	ProductType string `json:"productType"`
+	ProductId   uint   `json:"productId"`
+	Symbol      string `json:"symbol"`
+	Field       string `json:"field"`
+	LocalValue  string `json:"localValue,omitempty"`
+	RemoteValue string `json:"remoteValue,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InstrumentDriftReport struct {
+	ConnectionId uint            `json:"connectionId"`
+	CheckedAt    time.Time       `json:"checkedAt"`
+	Error        string          `json:"error,omitempty"`
+	Drifts       []*ProductDrift `json:"drifts"`
+}
+
+//=============================================================================
+
+func GetRemoteInstruments(tx *gorm.DB, c *auth.Context, id uint) (*[]RemoteInstrument, error) {
+	conn, instruments, err := getConnectionInstruments(tx, c, id, "GetRemoteInstruments")
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap, brokerMap, err := getConnectionProductMaps(tx, conn.Id)
+	if err != nil {
+		c.Log.Error("GetRemoteInstruments: Could not retrieve products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	exchangeIds := map[string]uint{}
+	list        := []RemoteInstrument{}
+
+	for _, ins := range *instruments {
+		ri := RemoteInstrument{ Instrument: ins }
+
+		ri.ExchangeId, err = getExchangeIdByCode(tx, exchangeIds, ins.ExchangeCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if dp, ok := dataMap[ins.Symbol]; ok {
+			ri.DataProductId = &dp.Id
+		}
+
+		if bp, ok := brokerMap[ins.Symbol]; ok {
+			ri.BrokerProductId = &bp.Id
+		}
+
+		list = append(list, ri)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func AddDataProductsFromInstruments(tx *gorm.DB, c *auth.Context, id uint, iss *InstrumentSelectionSpec) (*ProductImportResult, error) {
+	c.Log.Info("AddDataProductsFromInstruments: Adding data products from instruments", "id", id, "symbols", len(iss.Symbols))
+
+	conn, instruments, err := getConnectionInstruments(tx, c, id, "AddDataProductsFromInstruments")
+	if err != nil {
+		return nil, err
+	}
+
+	if !conn.SupportsData {
+		return nil, req.NewBadRequestError("Connection does not support data products: %v", id)
+	}
+
+	dataMap, _, err := getConnectionProductMaps(tx, conn.Id)
+	if err != nil {
+		c.Log.Error("AddDataProductsFromInstruments: Could not retrieve products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	insMap      := getInstrumentMap(instruments)
+	exchangeIds := map[string]uint{}
+	res         := &ProductImportResult{ Rows: []*ProductImportRow{} }
+
+	for i, symbol := range iss.Symbols {
+		row := &ProductImportRow{ Row: i + 1, Symbol: symbol }
+		res.Rows = append(res.Rows, row)
+
+		ins, message, exchangeId, err := checkSelectedInstrument(tx, insMap, exchangeIds, symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		if message == "" {
+			if _, ok := dataMap[symbol]; ok {
+				message = "A data product with this symbol already exists on the connection"
+			}
+		}
+
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		pds := DataProductSpec{
+			ConnectionId   : conn.Id,
+			ExchangeId     : exchangeId,
+			Symbol         : ins.Symbol,
+			Name           : ins.Name,
+			MarketType     : orDefault(ins.MarketType,  iss.MarketType),
+			ProductType    : orDefault(ins.ProductType, iss.ProductType),
+			Months         : ins.Months,
+			RolloverTrigger: iss.RolloverTrigger,
+		}
+
+		message = validateInstrumentSpec(&pds)
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		dp, err := AddDataProduct(tx, c, &pds)
+		if err != nil {
+			return nil, err
+		}
+
+		dataMap[symbol] = dp
+		res.addDone(row, dp.Id, ImportStatusCreated)
+	}
+
+	c.Log.Info("AddDataProductsFromInstruments: Data products added", "created", res.Created, "errors", res.Errors)
+	return res, nil
+}
+
+//=============================================================================
+
+func AddBrokerProductsFromInstruments(tx *gorm.DB, c *auth.Context, id uint, iss *InstrumentSelectionSpec) (*ProductImportResult, error) {
+	c.Log.Info("AddBrokerProductsFromInstruments: Adding broker products from instruments", "id", id, "symbols", len(iss.Symbols))
+
+	conn, instruments, err := getConnectionInstruments(tx, c, id, "AddBrokerProductsFromInstruments")
+	if err != nil {
+		return nil, err
+	}
+
+	if !conn.SupportsBroker {
+		return nil, req.NewBadRequestError("Connection does not support broker products: %v", id)
+	}
+
+	_, brokerMap, err := getConnectionProductMaps(tx, conn.Id)
+	if err != nil {
+		c.Log.Error("AddBrokerProductsFromInstruments: Could not retrieve products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	insMap      := getInstrumentMap(instruments)
+	exchangeIds := map[string]uint{}
+	res         := &ProductImportResult{ Rows: []*ProductImportRow{} }
+
+	for i, symbol := range iss.Symbols {
+		row := &ProductImportRow{ Row: i + 1, Symbol: symbol }
+		res.Rows = append(res.Rows, row)
+
+		ins, message, exchangeId, err := checkSelectedInstrument(tx, insMap, exchangeIds, symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		if message == "" {
+			if _, ok := brokerMap[symbol]; ok {
+				message = "A broker product with this symbol already exists on the connection"
+			}
+		}
+
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		bps := BrokerProductSpec{
+			ConnectionId    : conn.Id,
+			ExchangeId      : exchangeId,
+			Symbol          : ins.Symbol,
+			Name            : ins.Name,
+			PointValue      : ins.PointValue,
+			CostPerOperation: iss.CostPerOperation,
+			Increment       : ins.Increment,
+			MarketType      : orDefault(ins.MarketType,  iss.MarketType),
+			ProductType     : orDefault(ins.ProductType, iss.ProductType),
+		}
+
+		message = validateInstrumentSpec(&bps)
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		bp, err := AddBrokerProduct(tx, c, &bps)
+		if err != nil {
+			return nil, err
+		}
+
+		brokerMap[symbol] = bp
+		res.addDone(row, bp.Id, ImportStatusCreated)
+	}
+
+	c.Log.Info("AddBrokerProductsFromInstruments: Broker products added", "created", res.Created, "errors", res.Errors)
+	return res, nil
+}
+
+//=============================================================================
+
+func CheckInstrumentDrift(tx *gorm.DB, c *auth.Context, id uint) (*InstrumentDriftReport, error) {
+	conn, instruments, err := getConnectionInstruments(tx, c, id, "CheckInstrumentDrift")
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := ReconcileInstruments(tx, conn, instruments)
+	if err != nil {
+		c.Log.Error("CheckInstrumentDrift: Could not reconcile products", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	c.Log.Info("CheckInstrumentDrift: Products reconciled", "id", id, "drifts", len(report.Drifts))
+	return report, nil
+}
+
+//=============================================================================
+//--- Compares the products of the connection with the remote instruments
+
+func ReconcileInstruments(tx *gorm.DB, conn *db.Connection, instruments *[]platform.Instrument) (*InstrumentDriftReport, error) {
+	dataMap, brokerMap, err := getConnectionProductMaps(tx, conn.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	insMap      := getInstrumentMap(instruments)
+	exchangeIds := map[string]uint{}
+	report      := &InstrumentDriftReport{
+		ConnectionId: conn.Id,
+		CheckedAt   : time.Now(),
+		Drifts      : []*ProductDrift{},
+	}
+
+	for _, dp := range dataMap {
+		ins, ok := insMap[dp.Symbol]
+		if !ok {
+			report.add(DriftProductData, dp.Id, dp.Symbol, DriftFieldMissing, "", "")
+			continue
+		}
+
+		exchangeId, err := getExchangeIdByCode(tx, exchangeIds, ins.ExchangeCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if exchangeId != 0 && exchangeId != dp.ExchangeId {
+			report.add(DriftProductData, dp.Id, dp.Symbol, DriftFieldExchange, formatUint(dp.ExchangeId), ins.ExchangeCode)
+		}
+	}
+
+	for _, bp := range brokerMap {
+		ins, ok := insMap[bp.Symbol]
+		if !ok {
+			report.add(DriftProductBroker, bp.Id, bp.Symbol, DriftFieldMissing, "", "")
+			continue
+		}
+
+		exchangeId, err := getExchangeIdByCode(tx, exchangeIds, ins.ExchangeCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if exchangeId != 0 && exchangeId != bp.ExchangeId {
+			report.add(DriftProductBroker, bp.Id, bp.Symbol, DriftFieldExchange, formatUint(bp.ExchangeId), ins.ExchangeCode)
+		}
+
+		if ins.PointValue != 0 && ins.PointValue != bp.PointValue {
+			report.add(DriftProductBroker, bp.Id, bp.Symbol, DriftFieldPointValue, formatFloat(float64(bp.PointValue)), formatFloat(float64(ins.PointValue)))
+		}
+
+		if ins.Increment != 0 && math.Abs(ins.Increment - bp.Increment) > 1e-9 {
+			report.add(DriftProductBroker, bp.Id, bp.Symbol, DriftFieldIncrement, formatFloat(bp.Increment), formatFloat(ins.Increment))
+		}
+	}
+
+	return report, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func (r *InstrumentDriftReport) add(productType string, id uint, symbol, field, local, remote string) {
+	r.Drifts = append(r.Drifts, &ProductDrift{
+		ProductType: productType,
+		ProductId  : id,
+		Symbol     : symbol,
+		Field      : field,
+		LocalValue : local,
+		RemoteValue: remote,
+	})
+}
+
+//=============================================================================
+
+func getConnectionInstruments(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.Connection, *[]platform.Instrument, error) {
+	conn, err := getConnectionAndCheckAccess(tx, c, id, function)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !conn.SupportsInventory {
+		c.Log.Error(function +": Connection does not support the inventory", "id", id)
+		return nil, nil, req.NewBadRequestError("Connection does not support the inventory: %v", id)
+	}
+
+	instruments, err := platform.GetInstruments(c, conn.Username, conn.Code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, instruments, nil
+}
+
+//=============================================================================
+
+func getConnectionProductMaps(tx *gorm.DB, id uint) (map[string]*db.DataProduct, map[string]*db.BrokerProduct, error) {
+	dataList, err := db.GetDataProductsByConnectionId(tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	brokerList, err := db.GetBrokerProductsByConnectionId(tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataMap := map[string]*db.DataProduct{}
+	for _, dp := range *dataList {
+		dataMap[dp.Symbol] = &dp
+	}
+
+	brokerMap := map[string]*db.BrokerProduct{}
+	for _, bp := range *brokerList {
+		brokerMap[bp.Symbol] = &bp
+	}
+
+	return dataMap, brokerMap, nil
+}
+
+//=============================================================================
+
+func getInstrumentMap(instruments *[]platform.Instrument) map[string]*platform.Instrument {
+	insMap := map[string]*platform.Instrument{}
+	for _, ins := range *instruments {
+		insMap[ins.Symbol] = &ins
+	}
+
+	return insMap
+}
+
+//=============================================================================
+//--- Returns 0 if the exchange is not defined in the inventory
+
+func getExchangeIdByCode(tx *gorm.DB, exchangeIds map[string]uint, code string) (uint, error) {
+	if id, ok := exchangeIds[code]; ok {
+		return id, nil
+	}
+
+	ex, err := db.GetExchangeByCode(tx, code)
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint
+	if ex != nil {
+		id = ex.Id
+	}
+
+	exchangeIds[code] = id
+	return id, nil
+}
+
+//=============================================================================
+//--- Returns a non empty message if the instrument cannot be used
+
+func checkSelectedInstrument(tx *gorm.DB, insMap map[string]*platform.Instrument, exchangeIds map[string]uint, symbol string) (*platform.Instrument, string, uint, error) {
+	ins, ok := insMap[symbol]
+	if !ok {
+		return nil, "Instrument is not available on the connection", 0, nil
+	}
+
+	exchangeId, err := getExchangeIdByCode(tx, exchangeIds, ins.ExchangeCode)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if exchangeId == 0 {
+		return nil, "Exchange not found: "+ ins.ExchangeCode, 0, nil
+	}
+
+	return ins, "", exchangeId, nil
+}
+
+//=============================================================================
+
+func validateInstrumentSpec(spec any) string {
+	err := binding.Validator.ValidateStruct(spec)
+	if err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+//=============================================================================
+
+func orDefault(value, defValue string) string {
+	if value != "" {
+		return value
+	}
+
+	return defValue
+}
+
+//=============================================================================
+
+func formatUint(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
+
+//=============================================================================
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+//=============================================================================
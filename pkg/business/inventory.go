@@ -0,0 +1,1055 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"github.com/tradalia/inventory-server/pkg/platform"
+	"github.com/tradalia/sick-engine/session"
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Inventory documents reference entities by symbolic keys (connection and
+//=== exchange codes, product symbols, session and system names) so that they
+//=== can be moved between users and environments
+//===
+//=============================================================================
+
+const (
+	InventoryFormatJson = "json"
+	InventoryFormatYaml = "yaml"
+
+	InventoryVersion = 1
+)
+
+//=============================================================================
+
+type InventoryDocument struct {
+	Version         int                        `json:"version"`
+	Username        string                     `json:"username"`
+	ExportedAt      time.Time                  `json:"exportedAt"`
+	Connections     []InventoryConnection      `json:"connections"`
+	TradingSessions []InventoryTradingSession  `json:"tradingSessions"`
+	DataProducts    []InventoryDataProduct     `json:"dataProducts"`
+	BrokerProducts  []InventoryBrokerProduct   `json:"brokerProducts"`
+	TradingSystems  []InventoryTradingSystem   `json:"tradingSystems"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryConnection struct {
+	Code               string `json:"code"`
+	Name               string `json:"name"`
+	SystemCode         string `json:"systemCode"`
+	SystemConfigParams string `json:"systemConfigParams,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryTradingSession struct {
+	Name    string                  `json:"name"`
+	Session *session.TradingSession `json:"session"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryDataProduct struct {
+	Connection      string           `json:"connection"`
+	Exchange        string           `json:"exchange"`
+	Symbol          string           `json:"symbol"`
+	Name            string           `json:"name"`
+	MarketType      string           `json:"marketType"`
+	ProductType     string           `json:"productType"`
+	Months          string           `json:"months,omitempty"`
+	RolloverTrigger db.DPRollTrigger `json:"rolloverTrigger,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryBrokerProduct struct {
+	Connection       string  `json:"connection"`
+	Exchange         string  `json:"exchange"`
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
+	PointValue       float32 `json:"pointValue"`
+	CostPerOperation float32 `json:"costPerOperation"`
+	MarginValue      float32 `json:"marginValue"`
+	Increment        float64 `json:"increment"`
+	MarketType       string  `json:"marketType"`
+	ProductType      string  `json:"productType"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryTradingSystem struct {
+	Name           string `json:"name"`
+	DataProduct    string `json:"dataProduct"`
+	BrokerProduct  string `json:"brokerProduct"`
+	TradingSession string `json:"tradingSession"`
+	Timeframe      int    `json:"timeframe"`
+	StrategyType   string `json:"strategyType"`
+	Overnight      bool   `json:"overnight"`
+	Tags           string `json:"tags,omitempty"`
+	ExternalRef    string `json:"externalRef,omitempty"`
+}
+
+//=============================================================================
+
+const (
+	ImportStatusCreated  = "created"
+	ImportStatusUpdated  = "updated"
+	ImportStatusConflict = "conflict"
+	ImportStatusError    = "error"
+)
+
+//-----------------------------------------------------------------------------
+
+type ImportItemResult struct {
+	Type    string `json:"type"`
+	Key     string `json:"key"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type InventoryImportResult struct {
+	DryRun    bool                `json:"dryRun"`
+	Applied   bool                `json:"applied"`
+	Created   int                 `json:"created"`
+	Conflicts int                 `json:"conflicts"`
+	Errors    int                 `json:"errors"`
+	Items     []*ImportItemResult `json:"items"`
+}
+
+//=============================================================================
+
+func ExportInventory(tx *gorm.DB, c *auth.Context, username string) (*InventoryDocument, error) {
+	if username == "" {
+		username = c.Session.Username
+	}
+
+	if username != c.Session.Username && ! c.Session.IsAdmin() {
+		return nil, req.NewForbiddenError("Cannot export the inventory of another user: %v", username)
+	}
+
+	c.Log.Info("ExportInventory: Exporting inventory", "username", username)
+
+	exchanges, err := getExchangeCodes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := InventoryDocument{
+		Version   : InventoryVersion,
+		Username  : username,
+		ExportedAt: time.Now().UTC(),
+	}
+
+	//--- Connections
+
+	q := db.NewListQuery(&db.ConnectionQueryFields)
+	q.Filter["username"] = username
+
+	connections, err := db.GetConnections(tx, q, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	connCodes := map[uint]string{}
+
+	for _, conn := range *connections {
+		err = maskConnection(c, &conn)
+		if err != nil {
+			return nil, err
+		}
+
+		connCodes[conn.Id] = conn.Code
+		doc.Connections = append(doc.Connections, InventoryConnection{
+			Code              : conn.Code,
+			Name              : conn.Name,
+			SystemCode        : conn.SystemCode,
+			SystemConfigParams: conn.SystemConfigParams,
+		})
+	}
+
+	//--- Trading sessions
+
+	sessions, err := db.GetTradingSessions(tx, map[string]any{"username": username}, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionNames := map[uint]string{}
+
+	for _, se := range *sessions {
+		var sickTs session.TradingSession
+
+		err = json.Unmarshal([]byte(se.Config), &sickTs)
+		if err != nil {
+			c.Log.Error("ExportInventory: Invalid session config", "error", err.Error(), "id", se.Id)
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		sessionNames[se.Id] = se.Name
+		doc.TradingSessions = append(doc.TradingSessions, InventoryTradingSession{
+			Name   : se.Name,
+			Session: &sickTs,
+		})
+	}
+
+	//--- Data products
+
+	q = db.NewListQuery(&db.DataProductQueryFields)
+	q.Filter["username"] = username
+
+	dataProducts, err := db.GetDataProducts(tx, q, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	dpSymbols := map[uint]string{}
+
+	for _, dp := range *dataProducts {
+		dpSymbols[dp.Id] = dp.Symbol
+		doc.DataProducts = append(doc.DataProducts, InventoryDataProduct{
+			Connection     : connCodes[dp.ConnectionId],
+			Exchange       : exchanges[dp.ExchangeId],
+			Symbol         : dp.Symbol,
+			Name           : dp.Name,
+			MarketType     : dp.MarketType,
+			ProductType    : dp.ProductType,
+			Months         : dp.Months,
+			RolloverTrigger: dp.RolloverTrigger,
+		})
+	}
+
+	//--- Broker products
+
+	q = db.NewListQuery(&db.BrokerProductQueryFields)
+	q.Filter["username"] = username
+
+	brokerProducts, err := db.GetBrokerProducts(tx, q, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	bpSymbols := map[uint]string{}
+
+	for _, bp := range *brokerProducts {
+		bpSymbols[bp.Id] = bp.Symbol
+		doc.BrokerProducts = append(doc.BrokerProducts, InventoryBrokerProduct{
+			Connection      : connCodes[bp.ConnectionId],
+			Exchange        : exchanges[bp.ExchangeId],
+			Symbol          : bp.Symbol,
+			Name            : bp.Name,
+			PointValue      : bp.PointValue,
+			CostPerOperation: bp.CostPerOperation,
+			MarginValue     : bp.MarginValue,
+			Increment       : bp.Increment,
+			MarketType      : bp.MarketType,
+			ProductType     : bp.ProductType,
+		})
+	}
+
+	//--- Trading systems
+
+	q = db.NewListQuery(&db.TradingSystemQueryFields)
+	q.Filter["username"] = username
+
+	systems, err := db.GetTradingSystems(tx, q, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ts := range *systems {
+		doc.TradingSystems = append(doc.TradingSystems, InventoryTradingSystem{
+			Name          : ts.Name,
+			DataProduct   : dpSymbols[ts.DataProductId],
+			BrokerProduct : bpSymbols[ts.BrokerProductId],
+			TradingSession: sessionNames[ts.TradingSessionId],
+			Timeframe     : ts.Timeframe,
+			StrategyType  : ts.StrategyType,
+			Overnight     : ts.Overnight,
+			Tags          : ts.Tags,
+			ExternalRef   : ts.ExternalRef,
+		})
+	}
+
+	c.Log.Info("ExportInventory: Inventory exported", "username", username, "systems", len(doc.TradingSystems))
+	return &doc, nil
+}
+
+//=============================================================================
+//--- Entities that already exist are reported as conflicts and left untouched,
+//--- while references to them are still resolved. Nothing is written if any
+//--- item has errors or if a dry run is requested
+
+func ImportInventory(tx *gorm.DB, c *auth.Context, doc *InventoryDocument, dryRun bool) (*InventoryImportResult, error) {
+	c.Log.Info("ImportInventory: Importing inventory", "username", c.Session.Username, "dryRun", dryRun)
+
+	if doc.Version != InventoryVersion {
+		return nil, req.NewBadRequestError("Unsupported inventory version: %v", doc.Version)
+	}
+
+	imp, err := newInventoryImporter(tx, c, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = imp.run(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun || imp.result.Errors > 0 {
+		imp.result.DryRun = dryRun
+		c.Log.Info("ImportInventory: Inventory not applied", "dryRun", dryRun, "errors", imp.result.Errors)
+		return imp.result, nil
+	}
+
+	imp, err = newInventoryImporter(tx, c, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = imp.run(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	imp.result.Applied = true
+
+	c.Log.Info("ImportInventory: Inventory imported", "created", imp.result.Created, "conflicts", imp.result.Conflicts)
+	return imp.result, nil
+}
+
+//=============================================================================
+
+func EncodeInventory(doc *InventoryDocument, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	switch format {
+	case InventoryFormatJson:
+		return data, nil
+	case InventoryFormatYaml:
+		//--- Going through JSON keeps the same field names in both formats
+		var value any
+		err = json.Unmarshal(data, &value)
+		if err != nil {
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		data, err = yaml.Marshal(value)
+		if err != nil {
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		return data, nil
+	default:
+		return nil, req.NewBadRequestError("Unknown inventory format: %v", format)
+	}
+}
+
+//=============================================================================
+
+func DecodeInventory(data []byte, format string) (*InventoryDocument, error) {
+	switch format {
+	case InventoryFormatJson:
+	case InventoryFormatYaml:
+		var value any
+		err := yaml.Unmarshal(data, &value)
+		if err != nil {
+			return nil, req.NewBadRequestError("Invalid inventory file: %v", err.Error())
+		}
+
+		data, err = json.Marshal(value)
+		if err != nil {
+			return nil, req.NewBadRequestError("Invalid inventory file: %v", err.Error())
+		}
+	default:
+		return nil, req.NewBadRequestError("Unknown inventory format: %v", format)
+	}
+
+	var doc InventoryDocument
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, req.NewBadRequestError("Invalid inventory file: %v", err.Error())
+	}
+
+	return &doc, nil
+}
+
+//=============================================================================
+//===
+//=== Importer
+//===
+//=============================================================================
+
+const (
+	importTypeConnection     = "connection"
+	importTypeTradingSession = "tradingSession"
+	importTypeDataProduct    = "dataProduct"
+	importTypeBrokerProduct  = "brokerProduct"
+	importTypeTradingSystem  = "tradingSystem"
+)
+
+//=============================================================================
+//--- Keys map to the ids of existing or created entities. When planning, new
+//--- entities are mapped to 0 as they don't have an id yet
+
+type inventoryImporter struct {
+	tx             *gorm.DB
+	c              *auth.Context
+	apply          bool
+	result         *InventoryImportResult
+	exchanges      map[string]uint
+	connections    map[string]uint
+	sessions       map[string]uint
+	dataProducts   map[string]uint
+	brokerProducts map[string]uint
+	systems        map[string]uint
+	externalRefs   map[string]string
+	plannedData    map[string]*db.DataProduct
+	plannedBroker  map[string]*db.BrokerProduct
+}
+
+//=============================================================================
+
+func newInventoryImporter(tx *gorm.DB, c *auth.Context, apply bool) (*inventoryImporter, error) {
+	list, err := db.GetExchanges(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	exchanges := map[string]uint{}
+	for _, e := range *list {
+		exchanges[e.Code] = e.Id
+	}
+
+	return &inventoryImporter{
+		tx            : tx,
+		c             : c,
+		apply         : apply,
+		result        : &InventoryImportResult{ Items: []*ImportItemResult{} },
+		exchanges     : exchanges,
+		connections   : map[string]uint{},
+		sessions      : map[string]uint{},
+		dataProducts  : map[string]uint{},
+		brokerProducts: map[string]uint{},
+		systems       : map[string]uint{},
+		externalRefs  : map[string]string{},
+		plannedData   : map[string]*db.DataProduct{},
+		plannedBroker : map[string]*db.BrokerProduct{},
+	}, nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) run(doc *InventoryDocument) error {
+	for _, conn := range doc.Connections {
+		if err := imp.importConnection(&conn); err != nil {
+			return err
+		}
+	}
+
+	for _, se := range doc.TradingSessions {
+		if err := imp.importTradingSession(&se); err != nil {
+			return err
+		}
+	}
+
+	for _, dp := range doc.DataProducts {
+		if err := imp.importDataProduct(&dp); err != nil {
+			return err
+		}
+	}
+
+	for _, bp := range doc.BrokerProducts {
+		if err := imp.importBrokerProduct(&bp); err != nil {
+			return err
+		}
+	}
+
+	for _, ts := range doc.TradingSystems {
+		if err := imp.importTradingSystem(&ts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) importConnection(ic *InventoryConnection) error {
+	if ic.Code == "" || ic.Name == "" || ic.SystemCode == "" {
+		imp.addError(importTypeConnection, ic.Code, "Code, name and system code are required")
+		return nil
+	}
+
+	if _, ok := imp.connections[ic.Code]; ok {
+		imp.addError(importTypeConnection, ic.Code, "Connection is duplicated in the document")
+		return nil
+	}
+
+	//--- Exported secrets are masked and must be provided again
+	ic.SystemConfigParams = removeMaskedConfigParams(ic.SystemConfigParams)
+
+	conn, err := db.GetConnectionByCode(imp.tx, imp.c.Session.Username, ic.Code)
+	if err != nil {
+		return err
+	}
+
+	if conn != nil {
+		imp.connections[ic.Code] = conn.Id
+		imp.addConflict(importTypeConnection, ic.Code, "Connection already exists")
+		return nil
+	}
+
+	if !imp.apply {
+		sys, err := platform.GetSystem(imp.c, ic.SystemCode)
+		if err != nil {
+			return err
+		}
+
+		if sys == nil {
+			imp.addError(importTypeConnection, ic.Code, "System not found: "+ ic.SystemCode)
+			return nil
+		}
+
+		err = validateSystemConfigParams(sys, ic.SystemConfigParams)
+		if err != nil {
+			imp.addError(importTypeConnection, ic.Code, err.Error())
+			return nil
+		}
+
+		imp.connections[ic.Code] = 0
+		imp.addCreated(importTypeConnection, ic.Code)
+		return nil
+	}
+
+	conn, err = AddConnection(imp.tx, imp.c, &ConnectionSpec{
+		Code              : ic.Code,
+		Name              : ic.Name,
+		SystemCode        : ic.SystemCode,
+		SystemConfigParams: ic.SystemConfigParams,
+	})
+	if err != nil {
+		return err
+	}
+
+	imp.connections[ic.Code] = conn.Id
+	imp.addCreated(importTypeConnection, ic.Code)
+	return nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) importTradingSession(its *InventoryTradingSession) error {
+	if its.Name == "" || its.Session == nil {
+		imp.addError(importTypeTradingSession, its.Name, "Name and session are required")
+		return nil
+	}
+
+	if _, ok := imp.sessions[its.Name]; ok {
+		imp.addError(importTypeTradingSession, its.Name, "Trading session is duplicated in the document")
+		return nil
+	}
+
+	se, err := db.GetTradingSessionByName(imp.tx, imp.c.Session.Username, its.Name)
+	if err != nil {
+		return err
+	}
+
+	if se != nil {
+		imp.sessions[its.Name] = se.Id
+		imp.addConflict(importTypeTradingSession, its.Name, "Trading session already exists")
+		return nil
+	}
+
+	if !imp.apply {
+		imp.sessions[its.Name] = 0
+		imp.addCreated(importTypeTradingSession, its.Name)
+		return nil
+	}
+
+	config, err := json.Marshal(its.Session)
+	if err != nil {
+		return req.NewServerErrorByError(err)
+	}
+
+	se = &db.TradingSession{
+		Username: imp.c.Session.Username,
+		Name    : its.Name,
+		Config  : string(config),
+	}
+
+	err = db.AddTradingSession(imp.tx, se)
+	if err != nil {
+		imp.c.Log.Error("ImportInventory: Could not add trading session", "error", err.Error(), "name", its.Name)
+		return req.NewServerErrorByError(err)
+	}
+
+	imp.sessions[its.Name] = se.Id
+	imp.addCreated(importTypeTradingSession, its.Name)
+	return nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) importDataProduct(idp *InventoryDataProduct) error {
+	if idp.Symbol == "" || idp.Name == "" || idp.MarketType == "" || idp.ProductType == "" {
+		imp.addError(importTypeDataProduct, idp.Symbol, "Symbol, name, market type and product type are required")
+		return nil
+	}
+
+	if _, ok := imp.dataProducts[idp.Symbol]; ok {
+		imp.addError(importTypeDataProduct, idp.Symbol, "Data product is duplicated in the document")
+		return nil
+	}
+
+	list, err := db.GetDataProductsBySymbol(imp.tx, imp.c.Session.Username, idp.Symbol)
+	if err != nil {
+		return err
+	}
+
+	if len(*list) > 0 {
+		imp.dataProducts[idp.Symbol] = (*list)[0].Id
+		imp.addConflict(importTypeDataProduct, idp.Symbol, "Data product already exists")
+		return nil
+	}
+
+	connectionId, exchangeId, ok, err := imp.resolveProductRefs(importTypeDataProduct, idp.Symbol, idp.Connection, idp.Exchange)
+	if err != nil || !ok {
+		return err
+	}
+
+	pds := &DataProductSpec{
+		ConnectionId   : connectionId,
+		ExchangeId     : exchangeId,
+		Symbol         : idp.Symbol,
+		Name           : idp.Name,
+		MarketType     : idp.MarketType,
+		ProductType    : idp.ProductType,
+		Months         : idp.Months,
+		RolloverTrigger: idp.RolloverTrigger,
+	}
+
+	message, err := getClientErrorMessage(validateImportSpec(pds, getPendingIds(map[string]uint{"ConnectionId": connectionId})))
+	if err != nil {
+		return err
+	}
+
+	if message != "" {
+		imp.addError(importTypeDataProduct, idp.Symbol, message)
+		return nil
+	}
+
+	if !imp.apply {
+		imp.dataProducts[idp.Symbol] = 0
+		imp.plannedData[idp.Symbol] = &db.DataProduct{
+			ConnectionId: connectionId,
+			ExchangeId  : exchangeId,
+			Username    : imp.c.Session.Username,
+			Symbol      : idp.Symbol,
+			Name        : idp.Name,
+		}
+		imp.addCreated(importTypeDataProduct, idp.Symbol)
+		return nil
+	}
+
+	dp, err := AddDataProduct(imp.tx, imp.c, pds)
+	if err != nil {
+		return err
+	}
+
+	imp.dataProducts[idp.Symbol] = dp.Id
+	imp.addCreated(importTypeDataProduct, idp.Symbol)
+	return nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) importBrokerProduct(ibp *InventoryBrokerProduct) error {
+	if ibp.Symbol == "" || ibp.Name == "" || ibp.MarketType == "" || ibp.ProductType == "" {
+		imp.addError(importTypeBrokerProduct, ibp.Symbol, "Symbol, name, market type and product type are required")
+		return nil
+	}
+
+	if _, ok := imp.brokerProducts[ibp.Symbol]; ok {
+		imp.addError(importTypeBrokerProduct, ibp.Symbol, "Broker product is duplicated in the document")
+		return nil
+	}
+
+	list, err := db.GetBrokerProductsBySymbol(imp.tx, imp.c.Session.Username, ibp.Symbol)
+	if err != nil {
+		return err
+	}
+
+	if len(*list) > 0 {
+		imp.brokerProducts[ibp.Symbol] = (*list)[0].Id
+		imp.addConflict(importTypeBrokerProduct, ibp.Symbol, "Broker product already exists")
+		return nil
+	}
+
+	connectionId, exchangeId, ok, err := imp.resolveProductRefs(importTypeBrokerProduct, ibp.Symbol, ibp.Connection, ibp.Exchange)
+	if err != nil || !ok {
+		return err
+	}
+
+	bps := &BrokerProductSpec{
+		ConnectionId    : connectionId,
+		ExchangeId      : exchangeId,
+		Symbol          : ibp.Symbol,
+		Name            : ibp.Name,
+		PointValue      : ibp.PointValue,
+		CostPerOperation: ibp.CostPerOperation,
+		MarginValue     : ibp.MarginValue,
+		Increment       : ibp.Increment,
+		MarketType      : ibp.MarketType,
+		ProductType     : ibp.ProductType,
+	}
+
+	message, err := getClientErrorMessage(validateImportSpec(bps, getPendingIds(map[string]uint{"ConnectionId": connectionId})))
+	if err != nil {
+		return err
+	}
+
+	if message != "" {
+		imp.addError(importTypeBrokerProduct, ibp.Symbol, message)
+		return nil
+	}
+
+	if !imp.apply {
+		imp.brokerProducts[ibp.Symbol] = 0
+		imp.plannedBroker[ibp.Symbol] = &db.BrokerProduct{
+			ConnectionId: connectionId,
+			ExchangeId  : exchangeId,
+			Username    : imp.c.Session.Username,
+			Symbol      : ibp.Symbol,
+			Name        : ibp.Name,
+		}
+		imp.addCreated(importTypeBrokerProduct, ibp.Symbol)
+		return nil
+	}
+
+	bp, err := AddBrokerProduct(imp.tx, imp.c, bps)
+	if err != nil {
+		return err
+	}
+
+	imp.brokerProducts[ibp.Symbol] = bp.Id
+	imp.addCreated(importTypeBrokerProduct, ibp.Symbol)
+	return nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) importTradingSystem(its *InventoryTradingSystem) error {
+	if its.Name == "" {
+		imp.addError(importTypeTradingSystem, its.Name, "Name is required")
+		return nil
+	}
+
+	if _, ok := imp.systems[its.Name]; ok {
+		imp.addError(importTypeTradingSystem, its.Name, "Trading system is duplicated in the document")
+		return nil
+	}
+
+	list, err := db.GetTradingSystemsByName(imp.tx, imp.c.Session.Username, its.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(*list) > 0 {
+		imp.systems[its.Name] = (*list)[0].Id
+		imp.addConflict(importTypeTradingSystem, its.Name, "Trading system already exists")
+		return nil
+	}
+
+	dataProductId, ok := imp.dataProducts[its.DataProduct]
+	if !ok {
+		imp.addError(importTypeTradingSystem, its.Name, "Data product not found: "+ its.DataProduct)
+		return nil
+	}
+
+	brokerProductId, ok := imp.brokerProducts[its.BrokerProduct]
+	if !ok {
+		imp.addError(importTypeTradingSystem, its.Name, "Broker product not found: "+ its.BrokerProduct)
+		return nil
+	}
+
+	sessionId, ok := imp.sessions[its.TradingSession]
+	if !ok {
+		imp.addError(importTypeTradingSystem, its.Name, "Trading session not found: "+ its.TradingSession)
+		return nil
+	}
+
+	tss := &TradingSystemSpec{
+		DataProductId   : dataProductId,
+		BrokerProductId : brokerProductId,
+		TradingSessionId: sessionId,
+		Name            : its.Name,
+		Timeframe       : its.Timeframe,
+		StrategyType    : its.StrategyType,
+		Overnight       : its.Overnight,
+		Tags            : its.Tags,
+		ExternalRef     : its.ExternalRef,
+	}
+
+	message, err := getClientErrorMessage(imp.validateTradingSystem(its, tss))
+	if err != nil {
+		return err
+	}
+
+	if message != "" {
+		imp.addError(importTypeTradingSystem, its.Name, message)
+		return nil
+	}
+
+	if its.ExternalRef != "" {
+		imp.externalRefs[its.ExternalRef] = its.Name
+	}
+
+	if !imp.apply {
+		imp.systems[its.Name] = 0
+		imp.addCreated(importTypeTradingSystem, its.Name)
+		return nil
+	}
+
+	ts, err := AddTradingSystem(imp.tx, imp.c, tss)
+	if err != nil {
+		return err
+	}
+
+	imp.systems[its.Name] = ts.Id
+	imp.addCreated(importTypeTradingSystem, its.Name)
+	return nil
+}
+
+//=============================================================================
+//--- Runs the checks of AddTradingSystem in both passes, so that the dry run
+//--- predicts the apply. Products and sessions created by a dry run have no id
+//--- yet: products are checked as planned and sessions are owned by the user
+
+func (imp *inventoryImporter) validateTradingSystem(its *InventoryTradingSystem, tss *TradingSystemSpec) error {
+	pending := getPendingIds(map[string]uint{
+		"DataProductId"   : tss.DataProductId,
+		"BrokerProductId" : tss.BrokerProductId,
+		"TradingSessionId": tss.TradingSessionId,
+	})
+
+	err := validateImportSpec(tss, pending)
+	if err != nil {
+		return err
+	}
+
+	if name, ok := imp.externalRefs[tss.ExternalRef]; ok {
+		return req.AppError{
+			Code   : http.StatusConflict,
+			Message: "External reference is already used by trading system '"+ name +"': "+ tss.ExternalRef,
+		}
+	}
+
+	username := imp.c.Session.Username
+
+	err = validateStrategyType(imp.c, tss.StrategyType, "", "ImportInventory")
+	if err != nil {
+		return err
+	}
+
+	dp := imp.plannedData[its.DataProduct]
+	if tss.DataProductId != 0 {
+		dp, err = getOwnedDataProduct(imp.tx, imp.c, username, tss.DataProductId, "ImportInventory")
+		if err != nil {
+			return err
+		}
+	}
+
+	bp := imp.plannedBroker[its.BrokerProduct]
+	if tss.BrokerProductId != 0 {
+		bp, err = getOwnedBrokerProduct(imp.tx, imp.c, username, tss.BrokerProductId, "ImportInventory")
+		if err != nil {
+			return err
+		}
+	}
+
+	if tss.TradingSessionId != 0 {
+		err = checkOwnedTradingSession(imp.tx, imp.c, username, tss.TradingSessionId, "ImportInventory")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateTradingSystemRefs(imp.tx, imp.c, username, tss, dp, bp, "ImportInventory")
+	if err != nil {
+		return err
+	}
+
+	return checkExternalRefUniqueness(imp.tx, imp.c, username, tss.ExternalRef, 0, "ImportInventory")
+}
+
+//=============================================================================
+//--- Products can reference connections of the document or existing ones
+
+func (imp *inventoryImporter) resolveProductRefs(itemType string, key string, connection string, exchange string) (uint, uint, bool, error) {
+	connectionId, ok := imp.connections[connection]
+	if !ok {
+		conn, err := db.GetConnectionByCode(imp.tx, imp.c.Session.Username, connection)
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		if conn == nil {
+			imp.addError(itemType, key, "Connection not found: "+ connection)
+			return 0, 0, false, nil
+		}
+
+		connectionId = conn.Id
+		imp.connections[connection] = connectionId
+	}
+
+	exchangeId, ok := imp.exchanges[exchange]
+	if !ok {
+		imp.addError(itemType, key, "Exchange not found: "+ exchange)
+		return 0, 0, false, nil
+	}
+
+	return connectionId, exchangeId, true, nil
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) addCreated(itemType string, key string) {
+	imp.result.Created++
+	imp.addItem(itemType, key, ImportStatusCreated, "")
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) addConflict(itemType string, key string, message string) {
+	imp.result.Conflicts++
+	imp.addItem(itemType, key, ImportStatusConflict, message)
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) addError(itemType string, key string, message string) {
+	imp.result.Errors++
+	imp.addItem(itemType, key, ImportStatusError, message)
+}
+
+//=============================================================================
+
+func (imp *inventoryImporter) addItem(itemType string, key string, status string, message string) {
+	imp.result.Items = append(imp.result.Items, &ImportItemResult{
+		Type   : itemType,
+		Key    : key,
+		Status : status,
+		Message: message,
+	})
+}
+
+//=============================================================================
+//--- Entities created by a dry run have no id yet, so the fields referencing
+//--- them are not validated
+
+func validateImportSpec(spec any, pending []string) error {
+	var err error
+
+	if len(pending) == 0 {
+		err = binding.Validator.ValidateStruct(spec)
+	} else {
+		err = binding.Validator.Engine().(*validator.Validate).StructExcept(spec, pending...)
+	}
+
+	if err != nil {
+		return req.AppError{
+			Code   : http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Returns the reference fields having no id
+
+func getPendingIds(ids map[string]uint) []string {
+	var pending []string
+
+	for field, id := range ids {
+		if id == 0 {
+			pending = append(pending, field)
+		}
+	}
+
+	return pending
+}
+
+//=============================================================================
+//--- Client errors are reported on the imported item, server errors abort
+
+func getClientErrorMessage(err error) (string, error) {
+	if ae, ok := err.(req.AppError); ok && ae.Code < http.StatusInternalServerError {
+		return ae.Message, nil
+	}
+
+	return "", err
+}
+
+//=============================================================================
+
+func getExchangeCodes(tx *gorm.DB) (map[uint]string, error) {
+	list, err := db.GetExchanges(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := map[uint]string{}
+	for _, e := range *list {
+		codes[e.Id] = e.Code
+	}
+
+	return codes, nil
+}
+
+//=============================================================================
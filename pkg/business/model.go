@@ -25,6 +25,7 @@ THE SOFTWARE.
 package business
 
 import (
+	"github.com/tradalia/core/datatype"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"github.com/tradalia/sick-engine/session"
 )
@@ -55,30 +56,183 @@ type TradingSystemSpec struct {
 
 //=============================================================================
 
+type TradingSystemCloneSpec struct {
+	Username          string  `json:"username"`
+	DataProductId     *uint   `json:"dataProductId"`
+	BrokerProductId   *uint   `json:"brokerProductId"`
+	TradingSessionId  *uint   `json:"tradingSessionId"`
+	AgentProfileId    *uint   `json:"agentProfileId"`
+	ClearAgentProfile bool    `json:"clearAgentProfile"`
+	Name              *string `json:"name"`
+	Timeframe         *int    `json:"timeframe"         binding:"omitempty,min=1,max=1440"`
+	StrategyType      *string `json:"strategyType"`
+	Overnight         *bool   `json:"overnight"`
+	Tags              *string `json:"tags"`
+}
+
+//=============================================================================
+
+type TagSpec struct {
+	Name  string  `json:"name"  binding:"required"`
+}
+
+//=============================================================================
+
+type TagMergeSpec struct {
+	TagIds  []uint  `json:"tagIds"  binding:"required,min=1"`
+}
+
+//=============================================================================
+
+type TradingSystemTransitionSpec struct {
+	Status  db.TSStatus `json:"status"  binding:"required"`
+	Reason  string      `json:"reason"`
+}
+
+//=============================================================================
+
+type PortfolioSpec struct {
+	Name        string               `json:"name"        binding:"required"`
+	CurrencyId  uint                 `json:"currencyId"  binding:"required"`
+	Live        bool                 `json:"live"`
+	Systems     []PortfolioItemSpec  `json:"systems"     binding:"dive"`
+}
+
+//=============================================================================
+
+type PortfolioItemSpec struct {
+	TradingSystemId  uint     `json:"tradingSystemId"  binding:"required"`
+	Weight           float64  `json:"weight"           binding:"gte=0"`
+	Multiplier       int      `json:"multiplier"       binding:"omitempty,min=1"`
+}
+
+//=============================================================================
+
 type DataProductSpec struct {
-	ConnectionId    uint             `json:"connectionId"   binding:"required"`
-	ExchangeId      uint             `json:"exchangeId"     binding:"required"`
-	Symbol          string           `json:"symbol"         binding:"required"`
-	Name            string           `json:"name"           binding:"required"`
-	MarketType      string           `json:"marketType"     binding:"required"`
-	ProductType     string           `json:"productType"    binding:"required"`
-	Months          string           `json:"months"`
-	RolloverTrigger db.DPRollTrigger `json:"rolloverTrigger"`
+	ConnectionId     uint             `json:"connectionId"     binding:"required"`
+	ExchangeId       uint             `json:"exchangeId"       binding:"required"`
+	Symbol           string           `json:"symbol"           binding:"required"`
+	Name             string           `json:"name"             binding:"required"`
+	MarketType       string           `json:"marketType"       binding:"required"`
+	ProductType      string           `json:"productType"      binding:"required"`
+	Months           string           `json:"months"`
+	RolloverTrigger  db.DPRollTrigger `json:"rolloverTrigger"`
+	CatalogProductId *uint            `json:"catalogProductId"`
 }
 
 //=============================================================================
 
 type BrokerProductSpec struct {
+	ConnectionId     uint             `json:"connectionId"     binding:"required"`
+	ExchangeId       uint             `json:"exchangeId"       binding:"required"`
+	Symbol           string           `json:"symbol"           binding:"required"`
+	Name             string           `json:"name"             binding:"required"`
+	PointValue       float32          `json:"pointValue"       binding:"min=0,max=1000000"`
+	CostPerOperation float32          `json:"costPerOperation" binding:"min=0,max=10000"`
+	MarginValue      float32          `json:"marginValue"      binding:"min=0,max=1000000"`
+	Increment        float64          `json:"increment"        binding:"min=0,max=1"`
+	MarketType       string           `json:"marketType"       binding:"required"`
+	ProductType      string           `json:"productType"      binding:"required"`
+	CatalogProductId *uint            `json:"catalogProductId"`
+	EffectiveDate    datatype.IntDate `json:"effectiveDate"`
+}
+
+//=============================================================================
+
+type ProductMappingSpec struct {
+	DataProductId    uint    `json:"dataProductId"    binding:"required"`
+	BrokerProductId  uint    `json:"brokerProductId"  binding:"required"`
+	PriceMultiplier  float64 `json:"priceMultiplier"  binding:"omitempty,gt=0"`
+	BrokerSymbol     string  `json:"brokerSymbol"`
+}
+
+//=============================================================================
+
+type CommissionModelSpec struct {
+	Type          db.CommissionType    `json:"type"          binding:"required,oneof=fixed per-contract percentage tiered"`
+	CurrencyId    uint                 `json:"currencyId"    binding:"required"`
+	Fixed         float64              `json:"fixed"         binding:"min=0"`
+	PerContract   float64              `json:"perContract"   binding:"min=0"`
+	Percentage    float64              `json:"percentage"    binding:"min=0,max=100"`
+	ExchangeFee   float64              `json:"exchangeFee"   binding:"min=0"`
+	MinCommission float64              `json:"minCommission" binding:"min=0"`
+	MaxCommission float64              `json:"maxCommission" binding:"min=0"`
+	Tiers         []CommissionTierSpec `json:"tiers"         binding:"dive"`
+}
+
+//-----------------------------------------------------------------------------
+
+type CommissionTierSpec struct {
+	FromContracts int     `json:"fromContracts" binding:"min=1"`
+	PerContract   float64 `json:"perContract"   binding:"min=0"`
+}
+
+//=============================================================================
+
+type CatalogProductSpec struct {
+	ExchangeId   uint    `json:"exchangeId"   binding:"required"`
+	Symbol       string  `json:"symbol"       binding:"required"`
+	Name         string  `json:"name"         binding:"required"`
+	PointValue   float32 `json:"pointValue"   binding:"min=0,max=1000000"`
+	Increment    float64 `json:"increment"    binding:"min=0,max=1"`
+	Months       string  `json:"months"`
+	MarginValue  float32 `json:"marginValue"  binding:"min=0,max=1000000"`
+	MarketType   string  `json:"marketType"   binding:"required"`
+	ProductType  string  `json:"productType"  binding:"required"`
+}
+
+//=============================================================================
+//--- Products created from a catalog entry inherit its values. Symbol and
+//--- name can be overridden, if the connection uses different ones
+
+type CatalogDataProductSpec struct {
+	ConnectionId    uint             `json:"connectionId"    binding:"required"`
+	Symbol          string           `json:"symbol"`
+	Name            string           `json:"name"`
+	RolloverTrigger db.DPRollTrigger `json:"rolloverTrigger"`
+}
+
+//-----------------------------------------------------------------------------
+
+type CatalogBrokerProductSpec struct {
 	ConnectionId     uint    `json:"connectionId"     binding:"required"`
-	ExchangeId       uint    `json:"exchangeId"       binding:"required"`
-	Symbol           string  `json:"symbol"           binding:"required"`
-	Name             string  `json:"name"             binding:"required"`
-	PointValue       float32 `json:"pointValue"       binding:"min=0,max=1000000"`
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
 	CostPerOperation float32 `json:"costPerOperation" binding:"min=0,max=10000"`
-	MarginValue      float32 `json:"marginValue"      binding:"min=0,max=1000000"`
-	Increment        float64 `json:"increment"        binding:"min=0,max=1"`
-	MarketType       string  `json:"marketType"       binding:"required"`
-	ProductType      string  `json:"productType"      binding:"required"`
+}
+
+//=============================================================================
+
+type InstrumentSelectionSpec struct {
+	Symbols          []string         `json:"symbols"          binding:"required,min=1"`
+	MarketType       string           `json:"marketType"`
+	ProductType      string           `json:"productType"`
+	RolloverTrigger  db.DPRollTrigger `json:"rolloverTrigger"`
+	CostPerOperation float32          `json:"costPerOperation" binding:"min=0,max=10000"`
+}
+
+//=============================================================================
+
+type ExchangeSpec struct {
+	CurrencyId  uint   `json:"currencyId"  binding:"required"`
+	Code        string `json:"code"        binding:"required"`
+	Name        string `json:"name"        binding:"required"`
+	Timezone    string `json:"timezone"    binding:"required"`
+	Url         string `json:"url"`
+}
+
+//=============================================================================
+
+type CurrencyReviewOverrideSpec struct {
+	Value  float64 `json:"value" binding:"gt=0"`
+}
+
+//=============================================================================
+
+type UserPreferencesSpec struct {
+	ReportingCurrencyId      *uint  `json:"reportingCurrencyId"`
+	DefaultExchangeId        *uint  `json:"defaultExchangeId"`
+	DefaultTradingSessionId  *uint  `json:"defaultTradingSessionId"`
 }
 
 //=============================================================================
@@ -104,6 +258,28 @@ type BrokerProductExt struct {
 
 //=============================================================================
 
+type CommissionModelExt struct {
+	db.CommissionModel
+	Currency  db.Currency          `json:"currency"`
+	Tiers     []db.CommissionTier  `json:"tiers"`
+}
+
+//-----------------------------------------------------------------------------
+//--- Products without a commission model use their cost per operation
+
+type CommissionQuote struct {
+	BrokerProductId       uint    `json:"brokerProductId"`
+	Contracts             int     `json:"contracts"`
+	Price                 float64 `json:"price"`
+	Model                 string  `json:"model"`
+	Commission            float64 `json:"commission"`
+	CurrencyCode          string  `json:"currencyCode"`
+	ReportingCommission   float64 `json:"reportingCommission"`
+	ReportingCurrencyCode string  `json:"reportingCurrencyCode"`
+}
+
+//=============================================================================
+
 type DataProductExt struct {
 	db.DataProduct
 	Connection  db.Connection  `json:"connection,omitempty"`
@@ -128,6 +304,21 @@ type TradingSystemMessage struct {
 
 //=============================================================================
 
+type PortfolioExt struct {
+	db.Portfolio
+	Systems  []db.PortfolioItem  `json:"systems"`
+}
+
+//=============================================================================
+
+type PortfolioMessage struct {
+	Portfolio  db.Portfolio        `json:"portfolio"`
+	Currency   db.Currency         `json:"currency"`
+	Systems    []db.PortfolioItem  `json:"systems"`
+}
+
+//=============================================================================
+
 type DataProductMessage struct {
 	DataProduct db.DataProduct `json:"dataProduct"`
 	Connection  db.Connection  `json:"connection"`
@@ -137,10 +328,49 @@ type DataProductMessage struct {
 //=============================================================================
 
 type BrokerProductMessage struct {
-	BrokerProduct db.BrokerProduct `json:"brokerProduct"`
-	Connection    db.Connection    `json:"connection"`
-	Exchange      db.Exchange      `json:"exchange"`
-	Currency      db.Currency      `json:"currency"`
+	BrokerProduct   db.BrokerProduct    `json:"brokerProduct"`
+	Connection      db.Connection       `json:"connection"`
+	Exchange        db.Exchange         `json:"exchange"`
+	Currency        db.Currency         `json:"currency"`
+	CommissionModel *CommissionModelExt `json:"commissionModel,omitempty"`
+}
+
+//=============================================================================
+//--- Connect and disconnect commands for the system adapter
+
+type ConnectionCommandMessage struct {
+	ConnectionId   uint   `json:"connectionId"`
+	Username       string `json:"username"`
+	ConnectionCode string `json:"connectionCode"`
+	SystemCode     string `json:"systemCode"`
+}
+
+//=============================================================================
+
+//--- The core library doesn't define a source for exchanges yet
+
+const SourceExchange = "exchange"
+
+//-----------------------------------------------------------------------------
+
+type ExchangeMessage struct {
+	Exchange db.Exchange `json:"exchange"`
+	Currency db.Currency `json:"currency"`
+}
+
+//=============================================================================
+
+//--- Linked products are included to let consumers notify their owners
+
+const SourceCatalogProduct = "catalog-product"
+
+//-----------------------------------------------------------------------------
+
+type CatalogProductMessage struct {
+	CatalogProduct db.CatalogProduct  `json:"catalogProduct"`
+	Exchange       db.Exchange        `json:"exchange"`
+	DataProducts   []db.DataProduct   `json:"dataProducts"`
+	BrokerProducts []db.BrokerProduct `json:"brokerProducts"`
 }
 
 //=============================================================================
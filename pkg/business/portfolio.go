@@ -0,0 +1,333 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetPortfolios(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.Portfolio, int64, error) {
+	if ! c.Session.IsAdmin() {
+		filter["username"] = c.Session.Username
+	}
+
+	total, err := db.CountPortfolios(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := db.GetPortfolios(tx, filter, offset, limit)
+	return list, total, err
+}
+
+//=============================================================================
+
+func GetPortfolioById(tx *gorm.DB, c *auth.Context, id uint) (*PortfolioExt, error) {
+	p, err := getPortfolioAndCheckAccess(tx, c, id, "GetPortfolioById")
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := db.GetPortfolioItems(tx, p.Id)
+	if err != nil {
+		c.Log.Error("GetPortfolioById: Could not retrieve portfolio systems", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	return &PortfolioExt{
+		Portfolio: *p,
+		Systems  : *items,
+	}, nil
+}
+
+//=============================================================================
+
+func AddPortfolio(tx *gorm.DB, c *auth.Context, ps *PortfolioSpec) (*PortfolioExt, error) {
+	c.Log.Info("AddPortfolio: Adding a new portfolio", "name", ps.Name)
+
+	items, err := validatePortfolioSpec(tx, c, c.Session.Username, ps, "AddPortfolio")
+	if err != nil {
+		return nil, err
+	}
+
+	var p db.Portfolio
+	p.Username   = c.Session.Username
+	p.Name       = ps.Name
+	p.CurrencyId = ps.CurrencyId
+	p.Live       = ps.Live
+
+	err = db.AddPortfolio(tx, &p)
+	if err != nil {
+		c.Log.Error("AddPortfolio: Could not add a new portfolio", "error", err.Error())
+		return nil, err
+	}
+
+	err = db.SetPortfolioItems(tx, p.Id, items)
+	if err != nil {
+		c.Log.Error("AddPortfolio: Could not add the portfolio systems", "error", err.Error(), "id", p.Id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendPortfolioChangeMessage(tx, c, &p, items, msg.TypeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("AddPortfolio: Portfolio added", "id", p.Id)
+	return &PortfolioExt{ p, items }, nil
+}
+
+//=============================================================================
+
+func UpdatePortfolio(tx *gorm.DB, c *auth.Context, id uint, ps *PortfolioSpec) (*PortfolioExt, error) {
+	c.Log.Info("UpdatePortfolio: Updating a portfolio", "id", id, "name", ps.Name)
+
+	p, err := getPortfolioAndCheckAccess(tx, c, id, "UpdatePortfolio")
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := validatePortfolioSpec(tx, c, p.Username, ps, "UpdatePortfolio")
+	if err != nil {
+		return nil, err
+	}
+
+	p.Name       = ps.Name
+	p.CurrencyId = ps.CurrencyId
+	p.Live       = ps.Live
+
+	err = db.UpdatePortfolio(tx, p)
+	if err != nil {
+		c.Log.Error("UpdatePortfolio: Could not update a portfolio", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	err = db.SetPortfolioItems(tx, p.Id, items)
+	if err != nil {
+		c.Log.Error("UpdatePortfolio: Could not update the portfolio systems", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendPortfolioChangeMessage(tx, c, p, items, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("UpdatePortfolio: Portfolio updated", "id", p.Id, "name", p.Name)
+	return &PortfolioExt{ *p, items }, nil
+}
+
+//=============================================================================
+
+func DeletePortfolio(tx *gorm.DB, c *auth.Context, id uint) (*db.Portfolio, error) {
+	c.Log.Info("DeletePortfolio: Deleting portfolio", "id", id)
+
+	p, err := getPortfolioAndCheckAccess(tx, c, id, "DeletePortfolio")
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := db.GetPortfolioItems(tx, id)
+	if err != nil {
+		c.Log.Error("DeletePortfolio: Could not retrieve portfolio systems", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	err = db.DeletePortfolioItems(tx, id)
+	if err == nil {
+		err = db.DeletePortfolio(tx, id)
+	}
+
+	if err != nil {
+		c.Log.Error("DeletePortfolio: Cannot delete portfolio", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	err = sendPortfolioChangeMessage(tx, c, p, *items, msg.TypeDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("DeletePortfolio: Portfolio deleted", "id", id, "name", p.Name)
+	return p, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getPortfolioAndCheckAccess(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.Portfolio, error) {
+	p, err := db.GetPortfolioById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve portfolio", "error", err.Error())
+		return nil, err
+	}
+
+	if p == nil {
+		c.Log.Error(function +": Portfolio was not found", "id", id)
+		return nil, req.NewNotFoundError("Portfolio was not found: %v", id)
+	}
+
+	if ! c.Session.IsAdmin() {
+		if p.Username != c.Session.Username {
+			c.Log.Error(function +": Portfolio not owned by user", "id", id)
+			return nil, req.NewForbiddenError("Portfolio is not owned by user: %v", id)
+		}
+	}
+
+	return p, nil
+}
+
+//=============================================================================
+//--- Systems must belong to the owner of the portfolio and appear only once
+
+func validatePortfolioSpec(tx *gorm.DB, c *auth.Context, username string, ps *PortfolioSpec, function string) ([]db.PortfolioItem, error) {
+	cu, err := db.GetCurrencyById(tx, ps.CurrencyId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve currency", "error", err.Error())
+		return nil, err
+	}
+
+	if cu == nil {
+		c.Log.Error(function +": Currency was not found", "id", ps.CurrencyId)
+		return nil, req.NewNotFoundError("Currency was not found: %v", ps.CurrencyId)
+	}
+
+	items := []db.PortfolioItem{}
+	found := map[uint]bool{}
+
+	for _, pis := range ps.Systems {
+		if found[pis.TradingSystemId] {
+			c.Log.Error(function +": Trading system added more than once", "id", pis.TradingSystemId)
+			return nil, req.NewBadRequestError("Trading system added more than once: %v", pis.TradingSystemId)
+		}
+
+		found[pis.TradingSystemId] = true
+
+		ts, err := db.GetTradingSystemById(tx, pis.TradingSystemId)
+		if err != nil {
+			c.Log.Error(function +": Could not retrieve trading system", "error", err.Error())
+			return nil, err
+		}
+
+		if ts == nil {
+			c.Log.Error(function +": Trading system was not found", "id", pis.TradingSystemId)
+			return nil, req.NewNotFoundError("Trading system was not found: %v", pis.TradingSystemId)
+		}
+
+		if ts.Username != username {
+			c.Log.Error(function +": Trading system not owned by user", "id", pis.TradingSystemId)
+			return nil, req.NewForbiddenError("Trading system is not owned by user: %v", pis.TradingSystemId)
+		}
+
+		multiplier := pis.Multiplier
+		if multiplier == 0 {
+			multiplier = 1
+		}
+
+		items = append(items, db.PortfolioItem{
+			TradingSystemId: pis.TradingSystemId,
+			Weight         : pis.Weight,
+			Multiplier     : multiplier,
+		})
+	}
+
+	return items, nil
+}
+
+//=============================================================================
+//--- Systems of a live portfolio are trading and cannot be deleted. Other
+//--- portfolios just lose the system
+
+func removeTradingSystemFromPortfolios(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, function string) error {
+	list, err := db.GetPortfoliosByTradingSystemId(tx, ts.Id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve portfolios of trading system", "error", err.Error(), "id", ts.Id)
+		return err
+	}
+
+	for _, p := range *list {
+		if p.Live {
+			c.Log.Error(function +": Trading system belongs to a live portfolio", "id", ts.Id, "portfolioId", p.Id)
+			return req.NewUnprocessableEntityError("Trading system belongs to the live portfolio: %v", p.Name)
+		}
+	}
+
+	err = db.DeletePortfolioItemsByTradingSystemId(tx, ts.Id)
+	if err != nil {
+		c.Log.Error(function +": Could not remove trading system from portfolios", "error", err.Error(), "id", ts.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	for _, p := range *list {
+		items, err := db.GetPortfolioItems(tx, p.Id)
+		if err != nil {
+			c.Log.Error(function +": Could not retrieve portfolio systems", "error", err.Error(), "id", p.Id)
+			return err
+		}
+
+		err = sendPortfolioChangeMessage(tx, c, &p, *items, msg.TypeUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func sendPortfolioChangeMessage(tx *gorm.DB, c *auth.Context, p *db.Portfolio, items []db.PortfolioItem, msgType int) error {
+	cu, err := db.GetCurrencyById(tx, p.CurrencyId)
+	if err != nil {
+		c.Log.Error("sendPortfolioChangeMessage: Could not retrieve currency", "error", err.Error(), "id", p.Id)
+		return err
+	}
+
+	if cu == nil {
+		c.Log.Error("sendPortfolioChangeMessage: Currency was not found", "id", p.Id, "currencyId", p.CurrencyId)
+		return req.NewNotFoundError("Currency was not found: %v", p.CurrencyId)
+	}
+
+	pm := PortfolioMessage{*p, *cu, items}
+	err = msg.SendMessage(msg.ExInventory, msg.SourcePortfolio, msgType, &pm)
+
+	if err != nil {
+		c.Log.Error("sendPortfolioChangeMessage: Could not publish the change message", "error", err.Error(), "id", p.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
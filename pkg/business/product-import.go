@@ -0,0 +1,400 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Products can be imported from CSV files having a header row with the
+//=== JSON names of the spec fields. Connections and exchanges are given by
+//=== code, using the 'connection' and 'exchange' columns. Products are matched
+//=== by symbol: existing ones are updated, the others are created
+//===
+//=============================================================================
+
+type ProductImportRow struct {
+	Row     int    `json:"row"`
+	Symbol  string `json:"symbol"`
+	Status  string `json:"status"`
+	Id      uint   `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+//-----------------------------------------------------------------------------
+
+type ProductImportResult struct {
+	Created int                 `json:"created"`
+	Updated int                 `json:"updated"`
+	Errors  int                 `json:"errors"`
+	Rows    []*ProductImportRow `json:"rows"`
+}
+
+//=============================================================================
+
+func ImportDataProducts(tx *gorm.DB, c *auth.Context, data []byte) (*ProductImportResult, error) {
+	c.Log.Info("ImportDataProducts: Importing data products", "username", c.Session.Username)
+
+	records, err := parseProductsCsv(data)
+	if err != nil {
+		c.Log.Error("ImportDataProducts: Could not parse CSV file", "error", err.Error())
+		return nil, req.NewBadRequestError("Invalid CSV file: %v", err.Error())
+	}
+
+	res := &ProductImportResult{ Rows: []*ProductImportRow{} }
+
+	for i, record := range records {
+		row := &ProductImportRow{ Row: i + 2, Symbol: record["symbol"] }
+		res.Rows = append(res.Rows, row)
+
+		pds, message, err := buildDataProductSpec(tx, c, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		list, err := db.GetDataProductsBySymbol(tx, c.Session.Username, pds.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(*list) {
+		case 0:
+			dp, err := AddDataProduct(tx, c, pds)
+			if err != nil {
+				err = res.addFailure(row, err)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			res.addDone(row, dp.Id, ImportStatusCreated)
+
+		case 1:
+			dp, err := UpdateDataProduct(tx, c, (*list)[0].Id, pds)
+			if err != nil {
+				err = res.addFailure(row, err)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			res.addDone(row, dp.Id, ImportStatusUpdated)
+
+		default:
+			res.addError(row, "More than one data product has this symbol")
+		}
+	}
+
+	c.Log.Info("ImportDataProducts: Data products imported", "created", res.Created, "updated", res.Updated, "errors", res.Errors)
+	return res, nil
+}
+
+//=============================================================================
+
+func ImportBrokerProducts(tx *gorm.DB, c *auth.Context, data []byte) (*ProductImportResult, error) {
+	c.Log.Info("ImportBrokerProducts: Importing broker products", "username", c.Session.Username)
+
+	records, err := parseProductsCsv(data)
+	if err != nil {
+		c.Log.Error("ImportBrokerProducts: Could not parse CSV file", "error", err.Error())
+		return nil, req.NewBadRequestError("Invalid CSV file: %v", err.Error())
+	}
+
+	res := &ProductImportResult{ Rows: []*ProductImportRow{} }
+
+	for i, record := range records {
+		row := &ProductImportRow{ Row: i + 2, Symbol: record["symbol"] }
+		res.Rows = append(res.Rows, row)
+
+		list, err := db.GetBrokerProductsBySymbol(tx, c.Session.Username, record["symbol"])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(*list) > 1 {
+			res.addError(row, "More than one broker product has this symbol")
+			continue
+		}
+
+		var existing *db.BrokerProduct
+		if len(*list) == 1 {
+			existing = &(*list)[0]
+		}
+
+		bps, message, err := buildBrokerProductSpec(tx, c, record, existing)
+		if err != nil {
+			return nil, err
+		}
+
+		if message != "" {
+			res.addError(row, message)
+			continue
+		}
+
+		switch len(*list) {
+		case 0:
+			bp, err := AddBrokerProduct(tx, c, bps)
+			if err != nil {
+				err = res.addFailure(row, err)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			res.addDone(row, bp.Id, ImportStatusCreated)
+
+		case 1:
+			bp, err := UpdateBrokerProduct(tx, c, (*list)[0].Id, bps)
+			if err != nil {
+				err = res.addFailure(row, err)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			res.addDone(row, bp.Id, ImportStatusUpdated)
+		}
+	}
+
+	c.Log.Info("ImportBrokerProducts: Broker products imported", "created", res.Created, "updated", res.Updated, "errors", res.Errors)
+	return res, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func (r *ProductImportResult) addDone(row *ProductImportRow, id uint, status string) {
+	row.Id     = id
+	row.Status = status
+
+	if status == ImportStatusCreated {
+		r.Created++
+	} else {
+		r.Updated++
+	}
+}
+
+//=============================================================================
+
+func (r *ProductImportResult) addError(row *ProductImportRow, message string) {
+	row.Status  = ImportStatusError
+	row.Message = message
+	r.Errors++
+}
+
+//=============================================================================
+//--- Products rejected by validation are reported on the row, server errors abort
+
+func (r *ProductImportResult) addFailure(row *ProductImportRow, err error) error {
+	message, err := getClientErrorMessage(err)
+	if message != "" {
+		r.addError(row, message)
+	}
+
+	return err
+}
+
+//=============================================================================
+//--- Returns a non empty message if the row is invalid
+
+func buildDataProductSpec(tx *gorm.DB, c *auth.Context, record map[string]string) (*DataProductSpec, string, error) {
+	connectionId, exchangeId, message, err := resolveProductCodes(tx, c, record)
+	if err != nil || message != "" {
+		return nil, message, err
+	}
+
+	pds := &DataProductSpec{
+		ConnectionId   : connectionId,
+		ExchangeId     : exchangeId,
+		Symbol         : record["symbol"],
+		Name           : record["name"],
+		MarketType     : record["marketType"],
+		ProductType    : record["productType"],
+		Months         : record["months"],
+		RolloverTrigger: db.DPRollTrigger(record["rolloverTrigger"]),
+	}
+
+	err = binding.Validator.ValidateStruct(pds)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+
+	return pds, "", nil
+}
+
+//=============================================================================
+//--- Blank numeric cells keep the values of an existing product
+
+func buildBrokerProductSpec(tx *gorm.DB, c *auth.Context, record map[string]string, existing *db.BrokerProduct) (*BrokerProductSpec, string, error) {
+	connectionId, exchangeId, message, err := resolveProductCodes(tx, c, record)
+	if err != nil || message != "" {
+		return nil, message, err
+	}
+
+	bps := &BrokerProductSpec{
+		ConnectionId: connectionId,
+		ExchangeId  : exchangeId,
+		Symbol      : record["symbol"],
+		Name        : record["name"],
+		MarketType  : record["marketType"],
+		ProductType : record["productType"],
+	}
+
+	values := map[string]*float64{}
+	var pointValue, costPerOperation, marginValue float64
+
+	if existing != nil {
+		pointValue       = float64(existing.PointValue)
+		costPerOperation = float64(existing.CostPerOperation)
+		marginValue      = float64(existing.MarginValue)
+		bps.Increment    = existing.Increment
+	}
+
+	values["pointValue"]       = &pointValue
+	values["costPerOperation"] = &costPerOperation
+	values["marginValue"]      = &marginValue
+	values["increment"]        = &bps.Increment
+
+	for name, value := range values {
+		if record[name] == "" {
+			continue
+		}
+
+		*value, err = strconv.ParseFloat(record[name], 64)
+		if err != nil {
+			return nil, "Invalid number for "+ name +": "+ record[name], nil
+		}
+	}
+
+	bps.EffectiveDate, err = datatype.ParseIntDate(record["effectiveDate"], false)
+	if err != nil {
+		return nil, "Invalid effective date: "+ record["effectiveDate"], nil
+	}
+
+	bps.PointValue       = float32(pointValue)
+	bps.CostPerOperation = float32(costPerOperation)
+	bps.MarginValue      = float32(marginValue)
+
+	err = binding.Validator.ValidateStruct(bps)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+
+	return bps, "", nil
+}
+
+//=============================================================================
+
+func resolveProductCodes(tx *gorm.DB, c *auth.Context, record map[string]string) (uint, uint, string, error) {
+	conn, err := db.GetConnectionByCode(tx, c.Session.Username, record["connection"])
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	if conn == nil {
+		return 0, 0, "Connection not found: "+ record["connection"], nil
+	}
+
+	ex, err := db.GetExchangeByCode(tx, record["exchange"])
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	if ex == nil {
+		return 0, 0, "Exchange not found: "+ record["exchange"], nil
+	}
+
+	return conn.Id, ex.Id, "", nil
+}
+
+//=============================================================================
+//--- Each record maps the header names to the trimmed values of the row
+
+func parseProductsCsv(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var records []map[string]string
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		record := map[string]string{}
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+//=============================================================================
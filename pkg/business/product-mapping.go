@@ -0,0 +1,262 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetProductMappings(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.ProductMapping, int64, error) {
+	if ! c.Session.IsAdmin() {
+		filter["username"] = c.Session.Username
+	}
+
+	total, err := db.CountProductMappings(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := db.GetProductMappings(tx, filter, offset, limit)
+	return list, total, err
+}
+
+//=============================================================================
+
+func GetProductMappingById(tx *gorm.DB, c *auth.Context, id uint) (*db.ProductMapping, error) {
+	return getProductMapping(tx, c, id, "GetProductMappingById")
+}
+
+//=============================================================================
+
+func GetMappedBrokerProducts(tx *gorm.DB, c *auth.Context, dataProductId uint) (*[]db.MappedBrokerProduct, error) {
+	_, err := getDataProductAndCheckAccess(tx, c, dataProductId, "GetMappedBrokerProducts")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetMappedBrokerProducts(tx, dataProductId)
+}
+
+//=============================================================================
+
+func AddProductMapping(tx *gorm.DB, c *auth.Context, pms *ProductMappingSpec) (*db.ProductMapping, error) {
+	c.Log.Info("AddProductMapping: Adding a new product mapping", "dataProductId", pms.DataProductId, "brokerProductId", pms.BrokerProductId)
+
+	dp, err := getDataProductAndCheckAccess(tx, c, pms.DataProductId, "AddProductMapping")
+	if err != nil {
+		return nil, err
+	}
+
+	bp, err := getBrokerProductAndCheckAccess(tx, c, pms.BrokerProductId, "AddProductMapping")
+	if err != nil {
+		return nil, err
+	}
+
+	if dp.Username != bp.Username {
+		c.Log.Error("AddProductMapping: Products belong to different users", "dataProductId", dp.Id, "brokerProductId", bp.Id)
+		return nil, req.NewBadRequestError("Products belong to different users: %v", dp.Username +" / "+ bp.Username)
+	}
+
+	err = checkExchangeCompatibility(tx, c, dp, bp, "AddProductMapping")
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := db.GetProductMapping(tx, dp.Id, bp.Id)
+	if err != nil {
+		c.Log.Error("AddProductMapping: Could not retrieve product mapping", "error", err.Error())
+		return nil, err
+	}
+
+	if old != nil {
+		c.Log.Error("AddProductMapping: Products are already mapped", "id", old.Id)
+		return nil, req.AppError{
+			Code   : http.StatusConflict,
+			Message: fmt.Sprintf("Products are already mapped by mapping: %v", old.Id),
+		}
+	}
+
+	var pm db.ProductMapping
+	pm.Username        = dp.Username
+	pm.DataProductId   = dp.Id
+	pm.BrokerProductId = bp.Id
+	setProductMappingFields(&pm, bp, pms)
+
+	err = db.AddProductMapping(tx, &pm)
+	if err != nil {
+		c.Log.Error("AddProductMapping: Could not add a new product mapping", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info("AddProductMapping: Product mapping added", "id", pm.Id)
+	return &pm, nil
+}
+
+//=============================================================================
+//--- Only the translation can change: a different pair is a new mapping
+
+func UpdateProductMapping(tx *gorm.DB, c *auth.Context, id uint, pms *ProductMappingSpec) (*db.ProductMapping, error) {
+	c.Log.Info("UpdateProductMapping: Updating a product mapping", "id", id)
+
+	pm, err := getProductMapping(tx, c, id, "UpdateProductMapping")
+	if err != nil {
+		return nil, err
+	}
+
+	if pm.DataProductId != pms.DataProductId || pm.BrokerProductId != pms.BrokerProductId {
+		c.Log.Error("UpdateProductMapping: Mapped products cannot be changed", "id", id)
+		return nil, req.NewBadRequestError("Mapped products cannot be changed: %v", id)
+	}
+
+	bp, err := db.GetBrokerProductById(tx, pm.BrokerProductId)
+	if err != nil {
+		c.Log.Error("UpdateProductMapping: Could not retrieve broker product", "error", err.Error())
+		return nil, err
+	}
+
+	setProductMappingFields(pm, bp, pms)
+
+	err = db.UpdateProductMapping(tx, pm)
+	if err != nil {
+		c.Log.Error("UpdateProductMapping: Could not update the product mapping", "error", err.Error(), "id", id)
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info("UpdateProductMapping: Product mapping updated", "id", pm.Id)
+	return pm, nil
+}
+
+//=============================================================================
+
+func DeleteProductMapping(tx *gorm.DB, c *auth.Context, id uint) (*db.ProductMapping, error) {
+	c.Log.Info("DeleteProductMapping: Deleting product mapping", "id", id)
+
+	pm, err := getProductMapping(tx, c, id, "DeleteProductMapping")
+	if err != nil {
+		return nil, err
+	}
+
+	tss, err := db.GetTradingSystemsByProducts(tx, pm.DataProductId, pm.BrokerProductId)
+	if err != nil {
+		c.Log.Error("DeleteProductMapping: Could not retrieve trading systems", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if len(*tss) > 0 {
+		c.Log.Error("DeleteProductMapping: Mapping is still used by trading systems", "id", id, "tradingSystems", len(*tss))
+		return nil, req.NewUnprocessableEntityError("Mapping is still used by trading systems: %v", id)
+	}
+
+	err = db.DeleteProductMapping(tx, id)
+	if err != nil {
+		c.Log.Error("DeleteProductMapping: Cannot delete product mapping", "id", id, "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info("DeleteProductMapping: Product mapping deleted", "id", id)
+	return pm, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getProductMapping(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.ProductMapping, error) {
+	pm, err := db.GetProductMappingById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve product mapping", "error", err.Error())
+		return nil, err
+	}
+
+	if pm == nil {
+		c.Log.Error(function +": Product mapping was not found", "id", id)
+		return nil, req.NewNotFoundError("Product mapping was not found: %v", id)
+	}
+
+	if ! c.Session.IsAdmin() {
+		if pm.Username != c.Session.Username {
+			c.Log.Error(function +": Product mapping not owned by user", "id", id)
+			return nil, req.NewForbiddenError("Product mapping is not owned by user: %v", id)
+		}
+	}
+
+	return pm, nil
+}
+
+//=============================================================================
+
+func setProductMappingFields(pm *db.ProductMapping, bp *db.BrokerProduct, pms *ProductMappingSpec) {
+	pm.PriceMultiplier = pms.PriceMultiplier
+	pm.BrokerSymbol    = pms.BrokerSymbol
+
+	if pm.PriceMultiplier == 0 {
+		pm.PriceMultiplier = 1
+	}
+
+	if pm.BrokerSymbol == "" && bp != nil {
+		pm.BrokerSymbol = bp.Symbol
+	}
+}
+
+//=============================================================================
+//--- Data products without mappings can be traded with any compatible broker
+//--- product, so that existing systems keep working until mappings are added
+
+func checkProductMapping(tx *gorm.DB, c *auth.Context, dp *db.DataProduct, bp *db.BrokerProduct, function string) error {
+	count, err := db.CountProductMappingsByDataProductId(tx, dp.Id)
+	if err != nil {
+		c.Log.Error(function +": Could not count product mappings", "error", err.Error())
+		return err
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	pm, err := db.GetProductMapping(tx, dp.Id, bp.Id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve product mapping", "error", err.Error())
+		return err
+	}
+
+	if pm == nil {
+		c.Log.Error(function +": Broker product is not mapped to the data product", "dataProductId", dp.Id, "brokerProductId", bp.Id)
+		return req.NewUnprocessableEntityError("Broker product is not mapped to the data product: %v", bp.Symbol +" -> "+ dp.Symbol)
+	}
+
+	return nil
+}
+
+//=============================================================================
@@ -0,0 +1,362 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+const TagSeparator = ","
+
+//=============================================================================
+
+type TagBackfillResult struct {
+	TradingSystems int `json:"tradingSystems"`
+	Links          int `json:"links"`
+}
+
+//=============================================================================
+
+func GetTags(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]db.TagFull, int64, error) {
+	if ! c.Session.IsAdmin() {
+		filter["username"] = c.Session.Username
+	}
+
+	total, err := db.CountTags(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := db.GetTags(tx, filter, offset, limit)
+	return list, total, err
+}
+
+//=============================================================================
+
+func RenameTag(tx *gorm.DB, c *auth.Context, id uint, ts *TagSpec) (*db.Tag, error) {
+	c.Log.Info("RenameTag: Renaming tag", "id", id, "name", ts.Name)
+
+	t, err := getTag(tx, c, id, "RenameTag")
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(ts.Name)
+	if name == "" || strings.Contains(name, TagSeparator) {
+		c.Log.Error("RenameTag: Invalid tag name", "name", ts.Name)
+		return nil, req.NewBadRequestError("Invalid tag name: %v", ts.Name)
+	}
+
+	other, err := db.GetTagByName(tx, t.Username, name)
+	if err != nil {
+		c.Log.Error("RenameTag: Could not retrieve tag by name", "error", err.Error())
+		return nil, err
+	}
+
+	if other != nil && other.Id != t.Id {
+		c.Log.Error("RenameTag: A tag with the same name already exists", "id", id, "name", name)
+		return nil, req.AppError{
+			Code   : http.StatusConflict,
+			Message: "A tag with the same name already exists. Merge the tags instead: "+ name,
+		}
+	}
+
+	t.Name = name
+
+	err = db.UpdateTag(tx, t)
+	if err != nil {
+		c.Log.Error("RenameTag: Could not update tag", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	err = refreshTaggedTradingSystems(tx, c, t.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("RenameTag: Tag renamed", "id", id, "name", name)
+	return t, nil
+}
+
+//=============================================================================
+//--- Moves all systems of the given tags to the target tag and deletes them
+
+func MergeTags(tx *gorm.DB, c *auth.Context, id uint, tms *TagMergeSpec) (*db.Tag, error) {
+	c.Log.Info("MergeTags: Merging tags", "id", id, "tagIds", tms.TagIds)
+
+	target, err := getTag(tx, c, id, "MergeTags")
+	if err != nil {
+		return nil, err
+	}
+
+	tsIds, err := db.GetTradingSystemIdsByTagId(tx, target.Id)
+	if err != nil {
+		c.Log.Error("MergeTags: Could not retrieve systems of target tag", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	linked := map[uint]bool{}
+	for _, tsId := range tsIds {
+		linked[tsId] = true
+	}
+
+	for _, tagId := range tms.TagIds {
+		if tagId == target.Id {
+			continue
+		}
+
+		t, err := getTag(tx, c, tagId, "MergeTags")
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Username != target.Username {
+			c.Log.Error("MergeTags: Tags belong to different users", "id", id, "tagId", tagId)
+			return nil, req.NewUnprocessableEntityError("Tags belong to different users: %v", tagId)
+		}
+
+		ids, err := db.GetTradingSystemIdsByTagId(tx, t.Id)
+		if err != nil {
+			c.Log.Error("MergeTags: Could not retrieve systems of tag", "error", err.Error(), "id", tagId)
+			return nil, err
+		}
+
+		for _, tsId := range ids {
+			if !linked[tsId] {
+				err = db.AddTradingSystemTag(tx, tsId, target.Id)
+				if err != nil {
+					c.Log.Error("MergeTags: Could not link system to target tag", "error", err.Error(), "tsId", tsId)
+					return nil, req.NewServerErrorByError(err)
+				}
+
+				linked[tsId] = true
+			}
+		}
+
+		err = db.DeleteTagLinks(tx, t.Id)
+		if err == nil {
+			err = db.DeleteTag(tx, t.Id)
+		}
+
+		if err != nil {
+			c.Log.Error("MergeTags: Could not delete merged tag", "error", err.Error(), "id", tagId)
+			return nil, req.NewServerErrorByError(err)
+		}
+	}
+
+	err = refreshTaggedTradingSystems(tx, c, target.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("MergeTags: Tags merged", "id", id, "name", target.Name)
+	return target, nil
+}
+
+//=============================================================================
+//--- Links the systems created before tags were introduced. Existing links are
+//--- replaced, so the job can be run more than once
+
+func BackfillTags(tx *gorm.DB, c *auth.Context) (*TagBackfillResult, error) {
+	c.Log.Info("BackfillTags: Linking tags from the legacy tags column")
+
+	list, err := db.GetTradingSystemsWithLegacyTags(tx)
+	if err != nil {
+		c.Log.Error("BackfillTags: Could not retrieve trading systems", "error", err.Error())
+		return nil, err
+	}
+
+	res := &TagBackfillResult{}
+
+	for _, ts := range *list {
+		tags := parseTags(ts.Tags)
+
+		err = setTradingSystemTags(tx, c, &ts, tags)
+		if err != nil {
+			return nil, err
+		}
+
+		res.TradingSystems++
+		res.Links += len(tags)
+	}
+
+	c.Log.Info("BackfillTags: Tags linked", "tradingSystems", res.TradingSystems, "links", res.Links)
+	return res, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getTag(tx *gorm.DB, c *auth.Context, id uint, function string) (*db.Tag, error) {
+	t, err := db.GetTagById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve tag", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	if t == nil {
+		c.Log.Error(function +": Tag was not found", "id", id)
+		return nil, req.NewNotFoundError("Tag was not found: %v", id)
+	}
+
+	if t.Username != c.Session.Username && ! c.Session.IsAdmin() {
+		c.Log.Error(function +": Tag not owned by user", "id", id)
+		return nil, req.NewForbiddenError("Tag is not owned by user: %v", id)
+	}
+
+	return t, nil
+}
+
+//=============================================================================
+//--- Splits the legacy tags string, removing blanks and duplicates
+
+func parseTags(tags string) []string {
+	var names []string
+	found := map[string]bool{}
+
+	for _, name := range strings.Split(tags, TagSeparator) {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+
+		if name != "" && !found[key] {
+			found[key] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+//=============================================================================
+
+func setTradingSystemTags(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, names []string) error {
+	var tagIds []uint
+
+	for _, name := range names {
+		t, err := db.GetTagByName(tx, ts.Username, name)
+		if err != nil {
+			c.Log.Error("setTradingSystemTags: Could not retrieve tag", "error", err.Error(), "name", name)
+			return err
+		}
+
+		if t == nil {
+			t = &db.Tag{
+				Username: ts.Username,
+				Name    : name,
+			}
+
+			err = db.AddTag(tx, t)
+			if err != nil {
+				c.Log.Error("setTradingSystemTags: Could not add tag", "error", err.Error(), "name", name)
+				return req.NewServerErrorByError(err)
+			}
+		}
+
+		tagIds = append(tagIds, t.Id)
+	}
+
+	err := db.SetTradingSystemTags(tx, ts.Id, tagIds)
+	if err != nil {
+		c.Log.Error("setTradingSystemTags: Could not link tags to trading system", "error", err.Error(), "id", ts.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Rebuilds the legacy tags string of all systems linked to the tag
+
+func refreshTaggedTradingSystems(tx *gorm.DB, c *auth.Context, tagId uint) error {
+	tsIds, err := db.GetTradingSystemIdsByTagId(tx, tagId)
+	if err != nil {
+		c.Log.Error("refreshTaggedTradingSystems: Could not retrieve systems of tag", "error", err.Error(), "id", tagId)
+		return err
+	}
+
+	for _, tsId := range tsIds {
+		ts, err := db.GetTradingSystemById(tx, tsId)
+		if err != nil {
+			c.Log.Error("refreshTaggedTradingSystems: Could not retrieve trading system", "error", err.Error(), "id", tsId)
+			return err
+		}
+
+		tags, err := db.GetTagsByTradingSystemId(tx, tsId)
+		if err != nil {
+			c.Log.Error("refreshTaggedTradingSystems: Could not retrieve tags of trading system", "error", err.Error(), "id", tsId)
+			return err
+		}
+
+		var names []string
+		for _, t := range *tags {
+			names = append(names, t.Name)
+		}
+
+		old := *ts
+		ts.Tags = strings.Join(names, TagSeparator)
+
+		changes := diffTradingSystems(&old, ts)
+		if len(changes) == 0 {
+			continue
+		}
+
+		ts.Version, err = nextTradingSystemVersion(tx, c, &old)
+		if err != nil {
+			return err
+		}
+
+		err = db.UpdateTradingSystem(tx, ts)
+		if err != nil {
+			c.Log.Error("refreshTaggedTradingSystems: Could not update trading system", "error", err.Error(), "id", tsId)
+			return req.NewServerErrorByError(err)
+		}
+
+		err = addTradingSystemVersion(tx, c, ts, changes, ts.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		err = sendChangeMessage(tx, c, ts, msg.TypeUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
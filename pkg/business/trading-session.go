@@ -34,15 +34,20 @@ import (
 
 //=============================================================================
 
-func GetTradingSessions(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]TradingSession, error) {
+func GetTradingSessions(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int) (*[]TradingSession, int64, error) {
 	if ! c.Session.IsAdmin() {
 		filter["username"] = c.Session.Username
 	}
 
+	total, err := db.CountTradingSessions(tx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	list,err := db.GetTradingSessions(tx, filter, offset, limit)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var res []TradingSession
@@ -53,7 +58,7 @@ func GetTradingSessions(tx *gorm.DB, c *auth.Context, filter map[string]any, off
 		err = json.Unmarshal([]byte(dbTs.Config),&sickTs)
 		if err != nil {
 			c.Log.Error("GetTradingSessions: Invalid session config", "error", err.Error())
-			return nil, err
+			return nil, 0, err
 		}
 
 		busTs := TradingSession{
@@ -66,7 +71,7 @@ func GetTradingSessions(tx *gorm.DB, c *auth.Context, filter map[string]any, off
 		res = append(res, busTs)
 	}
 
-	return &res, nil
+	return &res, total, nil
 }
 
 //=============================================================================
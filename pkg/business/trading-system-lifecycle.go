@@ -0,0 +1,153 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+
+package business
+
+import (
+	"slices"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/msg"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+var tsTransitions = map[db.TSStatus][]db.TSStatus{
+	db.TSStatusDraft        : { db.TSStatusInDevelopment, db.TSStatusFinalized, db.TSStatusRetired },
+	db.TSStatusInDevelopment: { db.TSStatusDraft, db.TSStatusFinalized, db.TSStatusRetired },
+	db.TSStatusFinalized    : { db.TSStatusInDevelopment, db.TSStatusIncubation, db.TSStatusRetired },
+	db.TSStatusIncubation   : { db.TSStatusFinalized, db.TSStatusLive, db.TSStatusPaused, db.TSStatusRetired },
+	db.TSStatusLive         : { db.TSStatusPaused, db.TSStatusRetired },
+	db.TSStatusPaused       : { db.TSStatusIncubation, db.TSStatusLive, db.TSStatusRetired },
+	db.TSStatusRetired      : {},
+}
+
+//=============================================================================
+//--- Systems created before the lifecycle was introduced only have the
+//--- finalized flag
+
+func GetTradingSystemStatus(ts *db.TradingSystem) db.TSStatus {
+	if ts.Status != "" {
+		return ts.Status
+	}
+
+	if ts.Finalized {
+		return db.TSStatusFinalized
+	}
+
+	return db.TSStatusInDevelopment
+}
+
+//=============================================================================
+
+func TransitionTradingSystem(tx *gorm.DB, c *auth.Context, id uint, tsts *TradingSystemTransitionSpec) (*db.TradingSystem, error) {
+	c.Log.Info("TransitionTradingSystem: Changing trading system status", "id", id, "status", tsts.Status)
+
+	ts, err := getTradingSystem(tx, c, id, "TransitionTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	err = changeTradingSystemStatus(tx, c, ts, tsts.Status, tsts.Reason, "TransitionTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	err = sendChangeMessage(tx, c, ts, msg.TypeUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("TransitionTradingSystem: Trading system status changed", "id", ts.Id, "status", ts.Status)
+	return ts, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemTransitions(tx *gorm.DB, c *auth.Context, id uint) (*[]db.TradingSystemTransition, error) {
+	_, err := getTradingSystem(tx, c, id, "GetTradingSystemTransitions")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetTradingSystemTransitions(tx, id)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func changeTradingSystemStatus(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, to db.TSStatus, reason string, function string) error {
+	from := GetTradingSystemStatus(ts)
+
+	if _, found := tsTransitions[to]; !found {
+		c.Log.Error(function +": Unknown trading system status", "id", ts.Id, "status", to)
+		return req.NewBadRequestError("Unknown trading system status: %v", to)
+	}
+
+	if !slices.Contains(tsTransitions[from], to) {
+		c.Log.Error(function +": Transition not allowed", "id", ts.Id, "from", from, "to", to)
+		return req.NewUnprocessableEntityError("Transition not allowed: %v", string(from) +" -> "+ string(to))
+	}
+
+	ts.Status    = to
+	ts.Finalized = isFinalizedStatus(to)
+
+	err := db.UpdateTradingSystem(tx, ts)
+	if err != nil {
+		c.Log.Error(function +": Cannot update trading system status", "id", ts.Id, "error", err.Error())
+		return req.NewServerErrorByError(err)
+	}
+
+	tst := db.TradingSystemTransition{
+		TradingSystemId: ts.Id,
+		Username       : c.Session.Username,
+		FromStatus     : from,
+		ToStatus       : to,
+		Reason         : reason,
+	}
+
+	err = db.AddTradingSystemTransition(tx, &tst)
+	if err != nil {
+		c.Log.Error(function +": Cannot save trading system transition", "id", ts.Id, "error", err.Error())
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- The finalized flag is kept for consumers that don't know the lifecycle yet
+
+func isFinalizedStatus(status db.TSStatus) bool {
+	return status != db.TSStatusDraft && status != db.TSStatusInDevelopment
+}
+
+//=============================================================================
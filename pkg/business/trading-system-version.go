@@ -0,0 +1,271 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package business
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue any    `json:"oldValue"`
+	NewValue any    `json:"newValue"`
+}
+
+//=============================================================================
+
+type TradingSystemVersion struct {
+	Id               uint              `json:"id"`
+	TradingSystemId  uint              `json:"tradingSystemId"`
+	Version          int               `json:"version"`
+	Username         string            `json:"username"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	Snapshot         *db.TradingSystem `json:"snapshot"`
+	Changes          []FieldChange     `json:"changes"`
+}
+
+//=============================================================================
+
+func GetTradingSystemVersions(tx *gorm.DB, c *auth.Context, id uint) (*[]TradingSystemVersion, error) {
+	_, err := getTradingSystem(tx, c, id, "GetTradingSystemVersions")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := db.GetTradingSystemVersions(tx, id)
+	if err != nil {
+		c.Log.Error("GetTradingSystemVersions: Could not retrieve versions", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	res := []TradingSystemVersion{}
+
+	for _, dbTsv := range *list {
+		tsv, err := toTradingSystemVersion(&dbTsv)
+		if err != nil {
+			c.Log.Error("GetTradingSystemVersions: Invalid version snapshot", "error", err.Error(), "id", id, "version", dbTsv.Version)
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		res = append(res, *tsv)
+	}
+
+	return &res, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemAsOf(tx *gorm.DB, c *auth.Context, id uint, date datatype.IntDate) (*TradingSystemVersion, error) {
+	ts, err := getTradingSystem(tx, c, id, "GetTradingSystemAsOf")
+	if err != nil {
+		return nil, err
+	}
+
+	at := date.ToDateTime(true, time.UTC)
+
+	dbTsv, err := db.GetTradingSystemVersionAt(tx, id, at)
+	if err != nil {
+		c.Log.Error("GetTradingSystemAsOf: Could not retrieve version", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	if dbTsv != nil {
+		tsv, err := toTradingSystemVersion(dbTsv)
+		if err != nil {
+			c.Log.Error("GetTradingSystemAsOf: Invalid version snapshot", "error", err.Error(), "id", id, "version", dbTsv.Version)
+			return nil, req.NewServerErrorByError(err)
+		}
+
+		return tsv, nil
+	}
+
+	//--- Systems never changed since versioning was introduced have no history
+
+	if ts.Version == 0 && !ts.CreatedAt.After(at) {
+		return &TradingSystemVersion{
+			TradingSystemId: ts.Id,
+			Username       : ts.Username,
+			CreatedAt      : ts.CreatedAt,
+			Snapshot       : ts,
+		}, nil
+	}
+
+	c.Log.Error("GetTradingSystemAsOf: No version available at date", "id", id, "date", date)
+	return nil, req.NewNotFoundError("No version of the trading system available at date: %v", date.String())
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func toTradingSystemVersion(dbTsv *db.TradingSystemVersion) (*TradingSystemVersion, error) {
+	var snapshot db.TradingSystem
+	var changes  []FieldChange
+
+	err := json.Unmarshal([]byte(dbTsv.Snapshot), &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbTsv.Changes != "" {
+		err = json.Unmarshal([]byte(dbTsv.Changes), &changes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &TradingSystemVersion{
+		Id             : dbTsv.Id,
+		TradingSystemId: dbTsv.TradingSystemId,
+		Version        : dbTsv.Version,
+		Username       : dbTsv.Username,
+		CreatedAt      : dbTsv.CreatedAt,
+		Snapshot       : &snapshot,
+		Changes        : changes,
+	}, nil
+}
+
+//=============================================================================
+//--- Systems created before versioning get their current state saved as the
+//--- first version, so that the history starts from a known configuration
+
+func nextTradingSystemVersion(tx *gorm.DB, c *auth.Context, old *db.TradingSystem) (int, error) {
+	if old.Version > 0 {
+		return old.Version +1, nil
+	}
+
+	baseline := *old
+	baseline.Version = 1
+
+	err := addTradingSystemVersion(tx, c, &baseline, nil, baseline.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return 2, nil
+}
+
+//=============================================================================
+
+func addTradingSystemVersion(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, changes []FieldChange, at time.Time) error {
+	snapshot, err := json.Marshal(ts)
+	if err != nil {
+		c.Log.Error("addTradingSystemVersion: Cannot serialize trading system", "error", err.Error(), "id", ts.Id)
+		return req.NewServerErrorByError(err)
+	}
+
+	var sChanges []byte
+
+	if changes != nil {
+		sChanges, err = json.Marshal(changes)
+		if err != nil {
+			c.Log.Error("addTradingSystemVersion: Cannot serialize changes", "error", err.Error(), "id", ts.Id)
+			return req.NewServerErrorByError(err)
+		}
+	}
+
+	tsv := db.TradingSystemVersion{
+		TradingSystemId: ts.Id,
+		Version        : ts.Version,
+		Username       : c.Session.Username,
+		Snapshot       : string(snapshot),
+		Changes        : string(sChanges),
+		CreatedAt      : at,
+	}
+
+	err = db.AddTradingSystemVersion(tx, &tsv)
+	if err != nil {
+		c.Log.Error("addTradingSystemVersion: Cannot save version", "error", err.Error(), "id", ts.Id, "version", ts.Version)
+		return req.NewServerErrorByError(err)
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func diffTradingSystems(old, new *db.TradingSystem) []FieldChange {
+	var changes []FieldChange
+
+	oldVal := reflect.ValueOf(*old)
+	newVal := reflect.ValueOf(*new)
+	tsType := oldVal.Type()
+
+	for i := 0; i < tsType.NumField(); i++ {
+		field := tsType.Field(i)
+
+		//--- Skip id and timestamps
+		if field.Anonymous || field.Name == "Version" {
+			continue
+		}
+
+		o := reflect.Indirect(oldVal.Field(i))
+		n := reflect.Indirect(newVal.Field(i))
+
+		var ov, nv any
+		if o.IsValid() {
+			ov = o.Interface()
+		}
+		if n.IsValid() {
+			nv = n.Interface()
+		}
+
+		if !reflect.DeepEqual(ov, nv) {
+			changes = append(changes, FieldChange{
+				Field   : jsonFieldName(field),
+				OldValue: ov,
+				NewValue: nv,
+			})
+		}
+	}
+
+	return changes
+}
+
+//=============================================================================
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+//=============================================================================
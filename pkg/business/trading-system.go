@@ -25,6 +25,10 @@ THE SOFTWARE.
 package business
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/tradalia/core/auth"
 	"github.com/tradalia/core/msg"
 	"github.com/tradalia/core/req"
@@ -34,16 +38,25 @@ import (
 
 //=============================================================================
 
-func GetTradingSystems(tx *gorm.DB, c *auth.Context, filter map[string]any, offset int, limit int, details bool) (*[]db.TradingSystemFull, error) {
+func GetTradingSystems(tx *gorm.DB, c *auth.Context, q *db.ListQuery, offset int, limit int, details bool) (*[]db.TradingSystemFull, int64, error) {
 	if ! c.Session.IsAdmin() {
-		filter["username"] = c.Session.Username
+		q.Filter["username"] = c.Session.Username
 	}
 
+	total, err := db.CountTradingSystems(tx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list *[]db.TradingSystemFull
+
 	if details {
-		return db.GetTradingSystemsFull(tx, filter, offset, limit)
+		list, err = db.GetTradingSystemsFull(tx, q, offset, limit)
+	} else {
+		list, err = db.GetTradingSystems(tx, q, offset, limit)
 	}
 
-	return db.GetTradingSystems(tx, filter, offset, limit)
+	return list, total, err
 }
 
 //=============================================================================
@@ -51,7 +64,17 @@ func GetTradingSystems(tx *gorm.DB, c *auth.Context, filter map[string]any, offs
 func AddTradingSystem(tx *gorm.DB, c *auth.Context, tss *TradingSystemSpec) (*db.TradingSystem, error) {
 	c.Log.Info("AddTradingSystem: Adding a new trading system", "name", tss.Name)
 
-	//TODO: validate type
+	err := validateTradingSystemSpec(tx, c, c.Session.Username, tss, "", "AddTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkExternalRefUniqueness(tx, c, c.Session.Username, tss.ExternalRef, 0, "AddTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := parseTags(tss.Tags)
 
 	var ts db.TradingSystem
 	ts.Username         = c.Session.Username
@@ -63,15 +86,27 @@ func AddTradingSystem(tx *gorm.DB, c *auth.Context, tss *TradingSystemSpec) (*db
 	ts.Timeframe        = tss.Timeframe
 	ts.StrategyType     = tss.StrategyType
 	ts.Overnight        = tss.Overnight
-	ts.Tags             = tss.Tags
+	ts.Tags             = strings.Join(tags, TagSeparator)
 	ts.ExternalRef      = tss.ExternalRef
+	ts.Status           = db.TSStatusDraft
+	ts.Version          = 1
 
-	err := db.AddTradingSystem(tx, &ts)
+	err = db.AddTradingSystem(tx, &ts)
 	if err != nil {
 		c.Log.Error("AddTradingSystem: Could not add a new trading system", "error", err.Error())
 		return nil, err
 	}
 
+	err = setTradingSystemTags(tx, c, &ts, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addTradingSystemVersion(tx, c, &ts, nil, ts.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
 	err = sendChangeMessage(tx, c, &ts, msg.TypeCreate)
 	if err != nil {
 		return nil, err
@@ -91,7 +126,20 @@ func UpdateTradingSystem(tx *gorm.DB, c *auth.Context, id uint, tss *TradingSyst
 		return nil, err
 	}
 
-	//TODO: validate type
+	err = validateTradingSystemSpec(tx, c, ts.Username, tss, ts.StrategyType, "UpdateTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	if tss.ExternalRef != ts.ExternalRef {
+		err = checkExternalRefUniqueness(tx, c, ts.Username, tss.ExternalRef, ts.Id, "UpdateTradingSystem")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	old  := *ts
+	tags := parseTags(tss.Tags)
 
 	ts.DataProductId     = tss.DataProductId
 	ts.BrokerProductId   = tss.BrokerProductId
@@ -101,15 +149,35 @@ func UpdateTradingSystem(tx *gorm.DB, c *auth.Context, id uint, tss *TradingSyst
 	ts.Timeframe         = tss.Timeframe
 	ts.StrategyType      = tss.StrategyType
 	ts.Overnight         = tss.Overnight
-	ts.Tags              = tss.Tags
+	ts.Tags              = strings.Join(tags, TagSeparator)
 	ts.ExternalRef       = tss.ExternalRef
 
+	changes := diffTradingSystems(&old, ts)
+	if len(changes) > 0 {
+		ts.Version, err = nextTradingSystemVersion(tx, c, &old)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = db.UpdateTradingSystem(tx, ts)
 	if err != nil {
 		c.Log.Error("UpdateTradingSystem: Could not update a trading system", "error", err.Error(), "id", ts.Id)
 		return nil, err
 	}
 
+	err = setTradingSystemTags(tx, c, ts, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		err = addTradingSystemVersion(tx, c, ts, changes, ts.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = sendChangeMessage(tx, c, ts, msg.TypeUpdate)
 	if err != nil {
 		return nil, err
@@ -129,12 +197,22 @@ func DeleteTradingSystem(tx *gorm.DB, c *auth.Context, id uint) (*db.TradingSyst
 		return nil, err
 	}
 
-	err = db.DeleteTradingSystem(tx, id)
+	err = removeTradingSystemFromPortfolios(tx, c, ts, "DeleteTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.DeleteTradingSystemTags(tx, id)
+	if err == nil {
+		err = db.DeleteTradingSystem(tx, id)
+	}
+
 	if err != nil {
 		c.Log.Error("DeleteTradingSystem: Cannot delete trading system", "id", id, "error", err.Error())
 		return nil,req.NewServerErrorByError(err)
 	}
 
+	ts.Status = GetTradingSystemStatus(ts)
 	tsm := TradingSystemMessage{}
 	tsm.TradingSystem = ts
 	err = msg.SendMessage(msg.ExInventory, msg.SourceTradingSystem, msg.TypeDelete, &tsm)
@@ -148,6 +226,120 @@ func DeleteTradingSystem(tx *gorm.DB, c *auth.Context, id uint) (*db.TradingSyst
 	return ts, nil
 }
 
+//=============================================================================
+//--- Administrators can clone any system into any user's inventory. When the
+//--- target user is not the owner of the source, the overrides must reference
+//--- products owned by the target user
+
+func CloneTradingSystem(tx *gorm.DB, c *auth.Context, id uint, tscs *TradingSystemCloneSpec) (*db.TradingSystem, error) {
+	c.Log.Info("CloneTradingSystem: Cloning trading system", "id", id)
+
+	if tscs.ClearAgentProfile && tscs.AgentProfileId != nil {
+		c.Log.Error("CloneTradingSystem: Agent profile cannot be both set and cleared", "id", id)
+		return nil, req.NewBadRequestError("Agent profile cannot be both set and cleared: %v", *tscs.AgentProfileId)
+	}
+
+	src, err := getCloneSource(tx, c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	username := c.Session.Username
+
+	if tscs.Username != "" && tscs.Username != username {
+		if ! c.Session.IsAdmin() {
+			c.Log.Error("CloneTradingSystem: Only administrators can clone into another user's inventory", "id", id, "username", tscs.Username)
+			return nil, req.NewForbiddenError("Only administrators can clone into another user's inventory: %v", tscs.Username)
+		}
+
+		username = tscs.Username
+	}
+
+	if username != src.Username {
+		missing := getMissingCloneOverrides(src, tscs)
+		if len(missing) > 0 {
+			c.Log.Error("CloneTradingSystem: Missing overrides for another user's inventory", "id", id, "username", username, "fields", missing)
+			return nil, req.NewUnprocessableEntityError("Cloning into another user's inventory requires overrides for: %v", strings.Join(missing, ", "))
+		}
+	}
+
+	tss := TradingSystemSpec{
+		DataProductId   : src.DataProductId,
+		BrokerProductId : src.BrokerProductId,
+		TradingSessionId: src.TradingSessionId,
+		AgentProfileId  : src.AgentProfileId,
+		Name            : src.Name,
+		Timeframe       : src.Timeframe,
+		StrategyType    : src.StrategyType,
+		Overnight       : src.Overnight,
+		Tags            : src.Tags,
+	}
+
+	applyCloneOverrides(&tss, tscs)
+	tags := parseTags(tss.Tags)
+
+	err = validateTradingSystemSpec(tx, c, username, &tss, src.StrategyType, "CloneTradingSystem")
+	if err != nil {
+		return nil, err
+	}
+
+	ts := *src
+	ts.Common           = db.Common{}
+	ts.Username         = username
+	ts.DataProductId    = tss.DataProductId
+	ts.BrokerProductId  = tss.BrokerProductId
+	ts.TradingSessionId = tss.TradingSessionId
+	ts.AgentProfileId   = tss.AgentProfileId
+	ts.Name             = tss.Name
+	ts.Timeframe        = tss.Timeframe
+	ts.StrategyType     = tss.StrategyType
+	ts.Overnight        = tss.Overnight
+	ts.Tags             = strings.Join(tags, TagSeparator)
+	ts.ExternalRef      = ""
+	ts.Status           = db.TSStatusDraft
+	ts.Finalized        = false
+	ts.Version          = 1
+	ts.ParentId         = &src.Id
+
+	err = db.AddTradingSystem(tx, &ts)
+	if err != nil {
+		c.Log.Error("CloneTradingSystem: Could not add the cloned trading system", "error", err.Error(), "id", id)
+		return nil, err
+	}
+
+	err = setTradingSystemTags(tx, c, &ts, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addTradingSystemVersion(tx, c, &ts, nil, ts.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sendChangeMessage(tx, c, &ts, msg.TypeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Log.Info("CloneTradingSystem: Trading system cloned", "id", id, "cloneId", ts.Id, "username", username)
+	return &ts, nil
+}
+
+//=============================================================================
+//--- Lists external references shared by more than one system of the same user,
+//--- that were created before uniqueness was enforced and must be fixed by hand
+
+func GetExternalRefDuplicates(tx *gorm.DB, c *auth.Context) (*[]db.ExternalRefDuplicate, error) {
+	list, err := db.GetExternalRefDuplicates(tx)
+	if err != nil {
+		c.Log.Error("GetExternalRefDuplicates: Could not retrieve duplicated external references", "error", err.Error())
+		return nil, err
+	}
+
+	return list, nil
+}
+
 //=============================================================================
 
 const (
@@ -171,17 +363,15 @@ func FinalizeTradingSystem(tx *gorm.DB, c *auth.Context, id uint) (*Finalization
 		return nil, err
 	}
 
-	if ts.Finalized {
+	if isFinalizedStatus(GetTradingSystemStatus(ts)) {
 		return &FinalizationResponse{
 			Status: ResponseStatusSkipped,
 		}, nil
 	}
 
-	ts.Finalized = true
-	err = db.UpdateTradingSystem(tx, ts)
+	err = changeTradingSystemStatus(tx, c, ts, db.TSStatusFinalized, "Finalized", "FinalizeTradingSystem")
 	if err != nil {
-		c.Log.Error("FinalizeTradingSystem: Cannot finalize trading system", "id", id, "error", err.Error())
-		return nil,req.NewServerErrorByError(err)
+		return nil, err
 	}
 
 	err = sendChangeMessage(tx, c, ts, msg.TypeUpdate)
@@ -221,6 +411,279 @@ func getTradingSystem(tx *gorm.DB, c *auth.Context, id uint, funcName string) (*
 	return ts, nil
 }
 
+//=============================================================================
+//--- Administrators can clone systems owned by other users
+
+func getCloneSource(tx *gorm.DB, c *auth.Context, id uint) (*db.TradingSystem, error) {
+	if ! c.Session.IsAdmin() {
+		return getTradingSystem(tx, c, id, "CloneTradingSystem")
+	}
+
+	ts, err := db.GetTradingSystemById(tx, id)
+	if err != nil {
+		c.Log.Error("CloneTradingSystem: Could not retrieve trading system", "error", err.Error())
+		return nil,req.NewServerErrorByError(err)
+	}
+
+	if ts == nil {
+		c.Log.Error("CloneTradingSystem: Trading system was not found", "id", id)
+		return nil,req.NewNotFoundError("Trading system was not found: %v", id)
+	}
+
+	return ts, nil
+}
+
+//=============================================================================
+//--- References of the source belong to its owner and cannot be reused
+
+func getMissingCloneOverrides(src *db.TradingSystem, tscs *TradingSystemCloneSpec) []string {
+	var missing []string
+
+	if tscs.DataProductId == nil {
+		missing = append(missing, "dataProductId")
+	}
+	if tscs.BrokerProductId == nil {
+		missing = append(missing, "brokerProductId")
+	}
+	if tscs.TradingSessionId == nil {
+		missing = append(missing, "tradingSessionId")
+	}
+	if src.AgentProfileId != nil && tscs.AgentProfileId == nil && !tscs.ClearAgentProfile {
+		missing = append(missing, "agentProfileId (or clearAgentProfile)")
+	}
+
+	return missing
+}
+
+//=============================================================================
+
+func applyCloneOverrides(tss *TradingSystemSpec, tscs *TradingSystemCloneSpec) {
+	if tscs.DataProductId != nil {
+		tss.DataProductId = *tscs.DataProductId
+	}
+	if tscs.BrokerProductId != nil {
+		tss.BrokerProductId = *tscs.BrokerProductId
+	}
+	if tscs.TradingSessionId != nil {
+		tss.TradingSessionId = *tscs.TradingSessionId
+	}
+	if tscs.AgentProfileId != nil {
+		tss.AgentProfileId = tscs.AgentProfileId
+	}
+	if tscs.ClearAgentProfile {
+		tss.AgentProfileId = nil
+	}
+	if tscs.Name != nil {
+		tss.Name = *tscs.Name
+	}
+	if tscs.Timeframe != nil {
+		tss.Timeframe = *tscs.Timeframe
+	}
+	if tscs.StrategyType != nil {
+		tss.StrategyType = *tscs.StrategyType
+	}
+	if tscs.Overnight != nil {
+		tss.Overnight = *tscs.Overnight
+	}
+	if tscs.Tags != nil {
+		tss.Tags = *tscs.Tags
+	}
+}
+
+//=============================================================================
+//--- The agent scanner matches systems by external reference, so it must be
+//--- unique for each user
+
+func checkExternalRefUniqueness(tx *gorm.DB, c *auth.Context, username string, externalRef string, id uint, function string) error {
+	if externalRef == "" {
+		return nil
+	}
+
+	list, err := db.GetTradingSystemsByExtRef(tx, username, externalRef)
+	if err != nil {
+		c.Log.Error(function +": Could not check external reference", "error", err.Error())
+		return err
+	}
+
+	for _, ts := range *list {
+		if ts.Id != id {
+			c.Log.Error(function +": External reference already in use", "externalRef", externalRef, "id", ts.Id)
+			return req.AppError{
+				Code   : http.StatusConflict,
+				Message: fmt.Sprintf("External reference is already used by trading system '%v': %v", ts.Name, externalRef),
+			}
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- Referenced entities must exist and belong to the owner of the trading system
+
+func validateTradingSystemSpec(tx *gorm.DB, c *auth.Context, username string, tss *TradingSystemSpec, storedStrategyType string, function string) error {
+	err := validateStrategyType(c, tss.StrategyType, storedStrategyType, function)
+	if err != nil {
+		return err
+	}
+
+	dp, err := getOwnedDataProduct(tx, c, username, tss.DataProductId, function)
+	if err != nil {
+		return err
+	}
+
+	bp, err := getOwnedBrokerProduct(tx, c, username, tss.BrokerProductId, function)
+	if err != nil {
+		return err
+	}
+
+	err = checkOwnedTradingSession(tx, c, username, tss.TradingSessionId, function)
+	if err != nil {
+		return err
+	}
+
+	return validateTradingSystemRefs(tx, c, username, tss, dp, bp, function)
+}
+
+//=============================================================================
+//--- Systems created before strategy types were introduced can keep their stored
+//--- value until it is changed
+
+func validateStrategyType(c *auth.Context, strategyType string, storedStrategyType string, function string) error {
+	if _, ok := StrategyTypes[strategyType]; !ok && strategyType != storedStrategyType {
+		c.Log.Error(function +": Unknown strategy type", "strategyType", strategyType)
+		return req.NewBadRequestError("Unknown strategy type: %v", strategyType)
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func getOwnedDataProduct(tx *gorm.DB, c *auth.Context, username string, id uint, function string) (*db.DataProduct, error) {
+	dp, err := db.GetDataProductById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve data product", "error", err.Error())
+		return nil, err
+	}
+
+	if dp == nil {
+		c.Log.Error(function +": Data product was not found", "id", id)
+		return nil, req.NewNotFoundError("Data product was not found: %v", id)
+	}
+
+	if dp.Username != username {
+		c.Log.Error(function +": Data product not owned by user", "id", id)
+		return nil, req.NewForbiddenError("Data product is not owned by user: %v", id)
+	}
+
+	return dp, nil
+}
+
+//=============================================================================
+
+func getOwnedBrokerProduct(tx *gorm.DB, c *auth.Context, username string, id uint, function string) (*db.BrokerProduct, error) {
+	bp, err := db.GetBrokerProductById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve broker product", "error", err.Error())
+		return nil, err
+	}
+
+	if bp == nil {
+		c.Log.Error(function +": Broker product was not found", "id", id)
+		return nil, req.NewNotFoundError("Broker product was not found: %v", id)
+	}
+
+	if bp.Username != username {
+		c.Log.Error(function +": Broker product not owned by user", "id", id)
+		return nil, req.NewForbiddenError("Broker product is not owned by user: %v", id)
+	}
+
+	return bp, nil
+}
+
+//=============================================================================
+
+func checkOwnedTradingSession(tx *gorm.DB, c *auth.Context, username string, id uint, function string) error {
+	se, err := db.GetTradingSessionById(tx, id)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve trading session", "error", err.Error())
+		return err
+	}
+
+	if se == nil {
+		c.Log.Error(function +": Trading session was not found", "id", id)
+		return req.NewNotFoundError("Trading session was not found: %v", id)
+	}
+
+	if se.Username != username {
+		c.Log.Error(function +": Trading session not owned by user", "id", id)
+		return req.NewForbiddenError("Trading session is not owned by user: %v", id)
+	}
+
+	return nil
+}
+
+//=============================================================================
+//--- The products are resolved by the caller because the inventory import checks
+//--- products that do not exist yet
+
+func validateTradingSystemRefs(tx *gorm.DB, c *auth.Context, username string, tss *TradingSystemSpec, dp *db.DataProduct, bp *db.BrokerProduct, function string) error {
+	if tss.AgentProfileId != nil {
+		ap, err := db.GetAgentProfileById(tx, *tss.AgentProfileId)
+		if err != nil {
+			c.Log.Error(function +": Could not retrieve agent profile", "error", err.Error())
+			return err
+		}
+
+		if ap == nil {
+			c.Log.Error(function +": Agent profile was not found", "id", *tss.AgentProfileId)
+			return req.NewNotFoundError("Agent profile was not found: %v", *tss.AgentProfileId)
+		}
+
+		if ap.Username != username {
+			c.Log.Error(function +": Agent profile not owned by user", "id", *tss.AgentProfileId)
+			return req.NewForbiddenError("Agent profile is not owned by user: %v", *tss.AgentProfileId)
+		}
+	}
+
+	err := checkProductMapping(tx, c, dp, bp, function)
+	if err != nil {
+		return err
+	}
+
+	return checkExchangeCompatibility(tx, c, dp, bp, function)
+}
+
+//=============================================================================
+//--- Data and broker products can be on different exchanges (e.g. CME and CBOT)
+//--- as long as they share the trading hours timezone and the currency
+
+func checkExchangeCompatibility(tx *gorm.DB, c *auth.Context, dp *db.DataProduct, bp *db.BrokerProduct, function string) error {
+	if dp.ExchangeId == bp.ExchangeId {
+		return nil
+	}
+
+	dex, err := db.GetExchangeById(tx, dp.ExchangeId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve exchange of data product", "error", err.Error())
+		return err
+	}
+
+	bex, err := db.GetExchangeById(tx, bp.ExchangeId)
+	if err != nil {
+		c.Log.Error(function +": Could not retrieve exchange of broker product", "error", err.Error())
+		return err
+	}
+
+	if dex == nil || bex == nil || dex.Timezone != bex.Timezone || dex.CurrencyId != bex.CurrencyId {
+		c.Log.Error(function +": Data and broker products trade on incompatible exchanges", "dataProductId", dp.Id, "brokerProductId", bp.Id)
+		return req.NewUnprocessableEntityError("Data and broker products trade on incompatible exchanges: %v", dp.Symbol +" / "+ bp.Symbol)
+	}
+
+	return nil
+}
+
 //=============================================================================
 
 func sendChangeMessage(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, msgType int) error {
@@ -264,6 +727,7 @@ func sendChangeMessage(tx *gorm.DB, c *auth.Context, ts *db.TradingSystem, msgTy
 		}
 	}
 
+	ts.Status = GetTradingSystemStatus(ts)
 	tsm := TradingSystemMessage{ts, dp, bp, cu, se, ap, ex}
 	err = msg.SendMessage(msg.ExInventory, msg.SourceTradingSystem, msgType, &tsm)
 
@@ -0,0 +1,130 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+
+package business
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetUserPreferences(tx *gorm.DB, c *auth.Context) (*db.UserPreferences, error) {
+	up, err := db.GetUserPreferences(tx, c.Session.Username)
+	if err != nil {
+		c.Log.Error("GetUserPreferences: Could not retrieve user preferences", "error", err.Error())
+		return nil, err
+	}
+
+	if up == nil {
+		up = &db.UserPreferences{
+			Username: c.Session.Username,
+		}
+	}
+
+	return up, nil
+}
+
+//=============================================================================
+
+func SetUserPreferences(tx *gorm.DB, c *auth.Context, ups *UserPreferencesSpec) (*db.UserPreferences, error) {
+	c.Log.Info("SetUserPreferences: Setting user preferences", "username", c.Session.Username)
+
+	err := validateUserPreferencesSpec(tx, c, ups)
+	if err != nil {
+		return nil, err
+	}
+
+	up, err := GetUserPreferences(tx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	up.ReportingCurrencyId     = ups.ReportingCurrencyId
+	up.DefaultExchangeId       = ups.DefaultExchangeId
+	up.DefaultTradingSessionId = ups.DefaultTradingSessionId
+
+	err = db.SaveUserPreferences(tx, up)
+	if err != nil {
+		c.Log.Error("SetUserPreferences: Could not save user preferences", "error", err.Error())
+		return nil, req.NewServerErrorByError(err)
+	}
+
+	c.Log.Info("SetUserPreferences: User preferences set", "username", c.Session.Username)
+	return up, nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func validateUserPreferencesSpec(tx *gorm.DB, c *auth.Context, ups *UserPreferencesSpec) error {
+	if ups.ReportingCurrencyId != nil {
+		cu, err := db.GetCurrencyById(tx, *ups.ReportingCurrencyId)
+		if err != nil {
+			c.Log.Error("SetUserPreferences: Could not retrieve currency", "error", err.Error())
+			return err
+		}
+
+		if cu == nil {
+			c.Log.Error("SetUserPreferences: Currency was not found", "id", *ups.ReportingCurrencyId)
+			return req.NewNotFoundError("Currency was not found: %v", *ups.ReportingCurrencyId)
+		}
+	}
+
+	if ups.DefaultExchangeId != nil {
+		_, err := getExchange(tx, c, *ups.DefaultExchangeId, "SetUserPreferences")
+		if err != nil {
+			return err
+		}
+	}
+
+	if ups.DefaultTradingSessionId != nil {
+		se, err := db.GetTradingSessionById(tx, *ups.DefaultTradingSessionId)
+		if err != nil {
+			c.Log.Error("SetUserPreferences: Could not retrieve trading session", "error", err.Error())
+			return err
+		}
+
+		if se == nil {
+			c.Log.Error("SetUserPreferences: Trading session was not found", "id", *ups.DefaultTradingSessionId)
+			return req.NewNotFoundError("Trading session was not found: %v", *ups.DefaultTradingSessionId)
+		}
+
+		if se.Username != c.Session.Username {
+			c.Log.Error("SetUserPreferences: Trading session not owned by user", "id", *ups.DefaultTradingSessionId)
+			return req.NewForbiddenError("Trading session is not owned by user: %v", *ups.DefaultTradingSessionId)
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
@@ -0,0 +1,81 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package command
+
+import (
+	"log/slog"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/tradalia/core"
+)
+
+//=============================================================================
+//===
+//=== Commands for the system adapter are published on a dedicated exchange, so
+//=== that they don't reach the consumers of the inventory exchange. The core
+//=== messaging only declares its own exchanges, so this one is declared here
+//=== and the commands are published with msg.SendMessage
+//===
+//=============================================================================
+
+const (
+	ExInventoryCommand  = "bf.inventory.command"
+	QuInventoryToSystem = "bf.inventory:system"
+)
+
+//=============================================================================
+
+func Init(cfg *core.Messaging) {
+	slog.Info("Declaring command exchange...")
+
+	conn, err := amqp.Dial("amqp://"+ cfg.Username + ":" + cfg.Password + "@" + cfg.Address + "/")
+	if err != nil {
+		core.ExitWithMessage("Failed to connect to the messaging system: "+ err.Error())
+	}
+
+	defer conn.Close()
+
+	channel, err := conn.Channel()
+	if err != nil {
+		core.ExitWithMessage("Failed to get a channel from the messaging system: "+ err.Error())
+	}
+
+	err = channel.ExchangeDeclare(ExInventoryCommand,"fanout",true,false,false,false,nil)
+	if err != nil {
+		core.ExitWithMessage("Cannot create the '"+ ExInventoryCommand +"' exchange in the messaging system: "+ err.Error())
+	}
+
+	_, err = channel.QueueDeclare(QuInventoryToSystem,true,false,false,false,nil)
+	if err != nil {
+		core.ExitWithMessage("Cannot create the '"+ QuInventoryToSystem +"' queue in the messaging system: "+ err.Error())
+	}
+
+	err = channel.QueueBind(QuInventoryToSystem,"",ExInventoryCommand,false,nil)
+	if err != nil {
+		core.ExitWithMessage("Cannot bind queue '"+ QuInventoryToSystem +"' to the exchange: "+ err.Error())
+	}
+}
+
+//=============================================================================
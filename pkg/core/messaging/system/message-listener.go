@@ -27,6 +27,7 @@ package system
 import (
 	"encoding/json"
 	"github.com/tradalia/core/msg"
+	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 	"log/slog"
@@ -73,7 +74,7 @@ func handleSystemAdapterRestart() bool {
 	slog.Info("handleSystemAdapterRestart: Unsetting connection status flag to all connections")
 
 	err := db.RunInTransaction(func(tx *gorm.DB) error {
-		return db.DisconnectAll(tx)
+		return business.DisconnectAllConnections(tx, db.ConnectionSourceAdapterRestart)
 	})
 
 	if err != nil {
@@ -88,15 +89,28 @@ func handleSystemAdapterRestart() bool {
 //=============================================================================
 
 func handleConnectionChange(ccm *ConnectionChangeSystemMessage) bool {
-	if ccm.Status == ConnectionStatusConnecting {
-		return true
-	}
-
 	slog.Info("handleConnectionChange: Updating connection status", "user", ccm.Username, "connectionCode", ccm.ConnectionCode, "status", ccm.Status)
 
 	err := db.RunInTransaction(func(tx *gorm.DB) error {
-		connected := ccm.Status == ConnectionStatusConnected
-		return db.SetConnectionStatus(tx, ccm.Username, ccm.ConnectionCode, connected)
+		conn, err := db.GetConnectionByCode(tx, ccm.Username, ccm.ConnectionCode)
+		if err != nil {
+			return err
+		}
+
+		if conn == nil {
+			slog.Warn("handleConnectionChange: Connection not found. Skipping", "user", ccm.Username, "connectionCode", ccm.ConnectionCode)
+			return nil
+		}
+
+		status := db.ConnectionStatus(db.ConnectionStatusDisconnected)
+		switch ccm.Status {
+		case ConnectionStatusConnecting:
+			status = db.ConnectionStatusConnecting
+		case ConnectionStatusConnected:
+			status = db.ConnectionStatusConnected
+		}
+
+		return business.ChangeConnectionStatus(tx, conn, status, db.ConnectionSourceSystemAdapter)
 	})
 
 	if err != nil {
@@ -87,23 +87,58 @@ const (
 //=============================================================================
 
 type TradeItem struct {
-	TradeType       string       `json:"tradeType"`
-	EntryDate       *time.Time   `json:"entryDate"`
-	EntryPrice      float64      `json:"entryPrice"`
-	EntryLabel      string       `json:"entryLabel"`
-	ExitDate        *time.Time   `json:"exitDate"`
-	ExitPrice       float64      `json:"exitPrice"`
-	ExitLabel       string       `json:"exitLabel"`
-	GrossProfit     float64      `json:"grossProfit"`
-	Contracts       int          `json:"contracts"`
+	TradeType        string      `json:"tradeType"`
+	EntryDate        *time.Time  `json:"entryDate"`
+	EntryPrice       float64     `json:"entryPrice"`
+	EntryLabel       string      `json:"entryLabel"`
+	ExitDate         *time.Time  `json:"exitDate"`
+	ExitPrice        float64     `json:"exitPrice"`
+	ExitLabel        string      `json:"exitLabel"`
+	GrossProfit      float64     `json:"grossProfit"`
+	Contracts        int         `json:"contracts"`
+	CurrencyCode     string      `json:"currencyCode"`
+	ReportingCode    string      `json:"reportingCode"`
+	ReportingProfit  float64     `json:"reportingProfit"`
+	MarginValue      float32     `json:"marginValue"`
+	CostPerOperation float32     `json:"costPerOperation"`
 }
 
 //=============================================================================
 
 type DailyProfitItem struct {
-	Day         int      `json:"day"`
-	GrossProfit float64  `json:"grossProfit"`
-	Trades      int      `json:"trades"`
+	Day             int      `json:"day"`
+	GrossProfit     float64  `json:"grossProfit"`
+	Trades          int      `json:"trades"`
+	CurrencyCode    string   `json:"currencyCode"`
+	ReportingCode   string   `json:"reportingCode"`
+	ReportingProfit float64  `json:"reportingProfit"`
+}
+
+//=============================================================================
+
+const (
+	ScanStatusOk       = "ok"
+	ScanStatusNotFound = "not-found"
+	ScanStatusConflict = "conflict"
+	ScanStatusSkipped  = "skipped"
+)
+
+//=============================================================================
+
+type ScanResult struct {
+	AgentProfileId uint                `json:"agentProfileId"`
+	ScannedAt      time.Time           `json:"scannedAt"`
+	Error          string              `json:"error,omitempty"`
+	Systems        []*SystemScanResult `json:"systems"`
+}
+
+//=============================================================================
+
+type SystemScanResult struct {
+	ExternalRef      string `json:"externalRef"`
+	Status           string `json:"status"`
+	TradingSystemIds []uint `json:"tradingSystemIds,omitempty"`
+	Message          string `json:"message,omitempty"`
 }
 
 //=============================================================================
@@ -33,12 +33,14 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/tradalia/core/datatype"
 	"github.com/tradalia/core/msg"
 	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/app"
+	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
@@ -47,6 +49,9 @@ import (
 
 var agentMap map[uint]int = map[uint]int{}
 
+var scanResults      = map[uint]*ScanResult{}
+var scanResultsMutex sync.RWMutex
+
 //=============================================================================
 
 func Init(cfg *app.Config) *time.Ticker {
@@ -66,6 +71,15 @@ func Init(cfg *app.Config) *time.Ticker {
 
 //=============================================================================
 
+func GetScanResult(agentProfileId uint) *ScanResult {
+	scanResultsMutex.RLock()
+	defer scanResultsMutex.RUnlock()
+
+	return scanResults[agentProfileId]
+}
+
+//=============================================================================
+
 func run() {
 	agents,err := getAgentProfiles()
 	if err != nil {
@@ -126,17 +140,38 @@ func collectFromAgent(ap *db.AgentProfile) {
 
 	var data []TradingSystem
 
+	sr := &ScanResult{
+		AgentProfileId: ap.Id,
+		ScannedAt     : time.Now(),
+		Systems       : []*SystemScanResult{},
+	}
+
 	err := req.DoGet(client, ap.RemoteUrl, &data, "")
 
 	if err == nil {
 		slog.Info("Trades successfully retrieved from agent", "username", ap.Username, "systems", strconv.Itoa(len(data)), "agent", ap.Name)
 
-		_ = db.RunInTransaction(func (tx *gorm.DB) error {
-			return enqueueAgentTrades(tx, ap, data)
+		err = db.RunInTransaction(func (tx *gorm.DB) error {
+			return enqueueAgentTrades(tx, ap, data, sr)
 		})
 	} else {
 		slog.Error("Cannot connect to agent", "error", err.Error())
 	}
+
+	if err != nil {
+		sr.Error = err.Error()
+	}
+
+	setScanResult(sr)
+}
+
+//=============================================================================
+
+func setScanResult(sr *ScanResult) {
+	scanResultsMutex.Lock()
+	defer scanResultsMutex.Unlock()
+
+	scanResults[sr.AgentProfileId] = sr
 }
 
 //=============================================================================
@@ -172,28 +207,74 @@ func createClient(agentCert string, agentKey string, caCert string) *http.Client
 
 //=============================================================================
 
-func enqueueAgentTrades(tx *gorm.DB, ap *db.AgentProfile, agentTss []TradingSystem) error {
+func enqueueAgentTrades(tx *gorm.DB, ap *db.AgentProfile, agentTss []TradingSystem, sr *ScanResult) error {
 	for _, ats := range agentTss {
-		ts, err := db.GetTradingSystemByExtRef(tx, ap.Username, ats.Name)
+		list, err := db.GetTradingSystemsByExtRef(tx, ap.Username, ats.Name)
 
 		if err != nil {
 			slog.Error("enqueueAgentTrades: Cannot find trading system", "externalRef", ats.Name, "error", err.Error())
 			return err
 		}
 
-		if ts == nil {
+		ssr := &SystemScanResult{
+			ExternalRef: ats.Name,
+			Status     : ScanStatusOk,
+		}
+		sr.Systems = append(sr.Systems, ssr)
+
+		for _, ts := range *list {
+			ssr.TradingSystemIds = append(ssr.TradingSystemIds, ts.Id)
+		}
+
+		if len(*list) == 0 {
 			slog.Warn("Trading system was not found. Skipping", "externalRef", ats.Name, "username", ap.Username)
+			ssr.Status = ScanStatusNotFound
+			continue
+		}
+
+		if len(*list) > 1 {
+			slog.Warn("External reference is shared by more than one trading system. Skipping", "externalRef", ats.Name, "username", ap.Username, "ids", ssr.TradingSystemIds)
+			ssr.Status  = ScanStatusConflict
+			ssr.Message = "External reference is shared by more than one trading system"
+			continue
+		}
+
+		ts := &(*list)[0]
+
+		ex, err := getExchange(tx, ts)
+		if err != nil {
+			slog.Warn("Cannot retrieve exchange for trading system. Skipping", "externalRef", ats.Name, "username", ap.Username, "error", err)
+			ssr.Status  = ScanStatusSkipped
+			ssr.Message = "Cannot retrieve exchange: "+ err.Error()
 			continue
 		}
 
-		location, err := getLocation(tx, ts)
+		location, err := getLocation(ex)
 		if err != nil {
 			slog.Warn("Cannot retrieve timezone for trading system. Skipping", "externalRef", ats.Name, "username", ap.Username, "error", err)
+			ssr.Status  = ScanStatusSkipped
+			ssr.Message = "Cannot retrieve timezone: "+ err.Error()
+			continue
+		}
+
+		pc, err := newProfitConverter(tx, ts, ex)
+		if err != nil {
+			slog.Warn("Cannot retrieve currencies for trading system. Skipping", "externalRef", ats.Name, "username", ap.Username, "error", err)
+			ssr.Status  = ScanStatusSkipped
+			ssr.Message = "Cannot retrieve currencies: "+ err.Error()
+			continue
+		}
+
+		ch, err := newCostHistory(tx, ts)
+		if err != nil {
+			slog.Warn("Cannot retrieve costs for trading system. Skipping", "externalRef", ats.Name, "username", ap.Username, "error", err)
+			ssr.Status  = ScanStatusSkipped
+			ssr.Message = "Cannot retrieve costs: "+ err.Error()
 			continue
 		}
 
 		for _,tl := range ats.TradeLists {
-			err = sendTradeList(ts, ats.Name, tl, location)
+			err = sendTradeList(ts, ats.Name, tl, location, pc, ch)
 			if err != nil {
 				return err
 			}
@@ -205,19 +286,33 @@ func enqueueAgentTrades(tx *gorm.DB, ap *db.AgentProfile, agentTss []TradingSyst
 
 //=============================================================================
 
-func getLocation(tx *gorm.DB, ts *db.TradingSystem) (*time.Location, error) {
+func getExchange(tx *gorm.DB, ts *db.TradingSystem) (*db.Exchange, error) {
 	bp, err := db.GetBrokerProductById(tx, ts.BrokerProductId)
 	if err != nil {
-		slog.Error("getTimezone: Could not retrieve broker product of TS", "error", err.Error(), "id", ts.Id)
+		slog.Error("getExchange: Could not retrieve broker product of TS", "error", err.Error(), "id", ts.Id)
 		return nil, err
 	}
 
+	if bp == nil {
+		return nil, errors.New("broker product not found")
+	}
+
 	ex, err := db.GetExchangeById(tx, bp.ExchangeId)
 	if err != nil {
-		slog.Error("getTimezone: Could not retrieve exchange of TS", "error", err.Error(), "id", ts.Id)
+		slog.Error("getExchange: Could not retrieve exchange of TS", "error", err.Error(), "id", ts.Id)
 		return nil, err
 	}
 
+	if ex == nil {
+		return nil, errors.New("exchange not found")
+	}
+
+	return ex, nil
+}
+
+//=============================================================================
+
+func getLocation(ex *db.Exchange) (*time.Location, error) {
 	if ex.Timezone == "utc" {
 		return time.UTC, nil
 	}
@@ -227,13 +322,13 @@ func getLocation(tx *gorm.DB, ts *db.TradingSystem) (*time.Location, error) {
 
 //=============================================================================
 
-func sendTradeList(ts *db.TradingSystem, extRef string, tl *TradeList, location *time.Location) error {
+func sendTradeList(ts *db.TradingSystem, extRef string, tl *TradeList, location *time.Location, pc *profitConverter, ch *costHistory) error {
 	//--- Collect trades
 
 	var tradeList []*TradeItem
 
 	for _, atr := range tl.Trades {
-		tr := createTrade(extRef, atr, location)
+		tr := createTrade(extRef, atr, location, pc, ch)
 		if tr == nil {
 			return errors.New("aborted")
 		}
@@ -245,7 +340,7 @@ func sendTradeList(ts *db.TradingSystem, extRef string, tl *TradeList, location
 	var dayList []*DailyProfitItem
 
 	for _, adp := range tl.DailyProfits {
-		dp := createDailyProfit(adp, location)
+		dp := createDailyProfit(extRef, adp, location, pc)
 		if dp == nil {
 			return errors.New("aborted")
 		}
@@ -273,7 +368,7 @@ func sendTradeList(ts *db.TradingSystem, extRef string, tl *TradeList, location
 
 //=============================================================================
 
-func createTrade(extRef string, atr *Trade, loc *time.Location) *TradeItem {
+func createTrade(extRef string, atr *Trade, loc *time.Location, pc *profitConverter, ch *costHistory) *TradeItem {
 	tradeType := "?"
 
 	if atr.Position == 1 {
@@ -303,33 +398,145 @@ func createTrade(extRef string, atr *Trade, loc *time.Location) *TradeItem {
 		return nil
 	}
 
+	reportingProfit, err := pc.convert(atr.GrossProfit, datatype.ToIntDate(&exitDate))
+	if err != nil {
+		slog.Error("createTrade: Cannot convert gross profit", "exitDate", atr.ExitDate, "name", extRef, "error", err)
+		return nil
+	}
+
+	//--- Margin is required when the position is opened
+
+	cost := ch.at(datatype.ToIntDate(&entryDate))
+
 	return &TradeItem{
-		TradeType   : tradeType,
-		EntryDate   : &entryDate,
-		EntryPrice  : atr.EntryPrice,
-		EntryLabel  : atr.EntryLabel,
-		ExitDate    : &exitDate,
-		ExitPrice   : atr.ExitPrice,
-		ExitLabel   : atr.ExitLabel,
-		GrossProfit : atr.GrossProfit,
-		Contracts   : atr.Contracts,
+		TradeType       : tradeType,
+		EntryDate       : &entryDate,
+		EntryPrice      : atr.EntryPrice,
+		EntryLabel      : atr.EntryLabel,
+		ExitDate        : &exitDate,
+		ExitPrice       : atr.ExitPrice,
+		ExitLabel       : atr.ExitLabel,
+		GrossProfit     : atr.GrossProfit,
+		Contracts       : atr.Contracts,
+		CurrencyCode    : pc.product.Code,
+		ReportingCode   : pc.reporting.Code,
+		ReportingProfit : reportingProfit,
+		MarginValue     : cost.MarginValue,
+		CostPerOperation: cost.CostPerOperation,
 	}
 }
 
 //=============================================================================
 
-func createDailyProfit(dp *DailyProfit, loc *time.Location) *DailyProfitItem {
+func createDailyProfit(extRef string, dp *DailyProfit, loc *time.Location, pc *profitConverter) *DailyProfitItem {
 	date,err := parseDate(dp.Date, dp.Time, loc)
 	if err != nil {
 		slog.Error("createDailyProfit: Cannot parse date/time", "date", dp.Date, "time", dp.Time, "error", err)
 		return nil
 	}
 
+	day := datatype.ToIntDate(&date)
+
+	reportingProfit, err := pc.convert(dp.GrossProfit, day)
+	if err != nil {
+		slog.Error("createDailyProfit: Cannot convert gross profit", "date", dp.Date, "name", extRef, "error", err)
+		return nil
+	}
+
 	return &DailyProfitItem{
-		Day        : int(datatype.ToIntDate(&date)),
-		GrossProfit: dp.GrossProfit,
-		Trades     : dp.Trades,
+		Day            : int(day),
+		GrossProfit    : dp.GrossProfit,
+		Trades         : dp.Trades,
+		CurrencyCode   : pc.product.Code,
+		ReportingCode  : pc.reporting.Code,
+		ReportingProfit: reportingProfit,
+	}
+}
+
+//=============================================================================
+//===
+//=== Profit converter
+//===
+//=============================================================================
+
+type profitConverter struct {
+	converter *business.CurrencyConverter
+	product   *db.Currency
+	reporting *db.Currency
+}
+
+//=============================================================================
+
+func newProfitConverter(tx *gorm.DB, ts *db.TradingSystem, ex *db.Exchange) (*profitConverter, error) {
+	product, err := db.GetCurrencyById(tx, ex.CurrencyId)
+	if err != nil {
+		slog.Error("newProfitConverter: Could not retrieve currency of TS", "error", err.Error(), "id", ts.Id)
+		return nil, err
 	}
+
+	if product == nil {
+		return nil, errors.New("currency not found")
+	}
+
+	reporting, err := business.GetReportingCurrency(tx, ts.Username)
+	if err != nil {
+		slog.Error("newProfitConverter: Could not retrieve reporting currency", "error", err.Error(), "username", ts.Username)
+		return nil, err
+	}
+
+	return &profitConverter{
+		converter: business.NewCurrencyConverter(tx),
+		product  : product,
+		reporting: reporting,
+	}, nil
+}
+
+//=============================================================================
+
+func (pc *profitConverter) convert(amount float64, date datatype.IntDate) (float64, error) {
+	return pc.converter.Convert(amount, pc.product, pc.reporting, date)
+}
+
+//=============================================================================
+//===
+//=== Cost history
+//===
+//=============================================================================
+
+type costHistory struct {
+	product *db.BrokerProduct
+	list    []db.BrokerProductCost
+}
+
+//=============================================================================
+
+func newCostHistory(tx *gorm.DB, ts *db.TradingSystem) (*costHistory, error) {
+	bp, err := db.GetBrokerProductById(tx, ts.BrokerProductId)
+	if err != nil {
+		slog.Error("newCostHistory: Could not retrieve broker product of TS", "error", err.Error(), "id", ts.Id)
+		return nil, err
+	}
+
+	if bp == nil {
+		return nil, errors.New("broker product not found")
+	}
+
+	list, err := db.GetBrokerProductCosts(tx, bp.Id)
+	if err != nil {
+		slog.Error("newCostHistory: Could not retrieve cost history", "error", err.Error(), "id", bp.Id)
+		return nil, err
+	}
+
+	return &costHistory{
+		product: bp,
+		list   : *list,
+	}, nil
+}
+
+//=============================================================================
+
+func (ch *costHistory) at(date datatype.IntDate) db.BrokerProductCost {
+	return business.BrokerProductCostAt(ch.product, ch.list, date)
 }
 
 //=============================================================================
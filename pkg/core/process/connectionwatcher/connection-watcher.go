@@ -0,0 +1,77 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package connectionwatcher
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/tradalia/inventory-server/pkg/app"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Disconnects the connections still waiting for a confirmation from the
+//=== system adapter after the connect timeout
+//===
+//=============================================================================
+
+func Init(cfg *app.Config) *time.Ticker {
+	ticker := time.NewTicker(30 * time.Second)
+
+	go func() {
+		for range ticker.C {
+			run()
+		}
+	}()
+
+	return ticker
+}
+
+//=============================================================================
+
+func run() {
+	var expired int
+
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		var err error
+		expired, err = business.ExpireConnectingConnections(tx)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("ConnectionWatcher: Cannot expire pending connections", "error", err)
+		return
+	}
+
+	if expired > 0 {
+		slog.Warn("ConnectionWatcher: Pending connections timed out", "connections", expired)
+	}
+}
+
+//=============================================================================
@@ -0,0 +1,128 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+
+package currencyupdater
+
+import (
+	"log/slog"
+	"math"
+
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+const (
+	DefaultAnomalyThreshold = 10.0
+	DefaultAnomalyDays      = 5
+)
+
+var anomalyThreshold float64
+var anomalyDays      int
+
+//=============================================================================
+
+func initAnomalyDetector(threshold float64, days int) {
+	anomalyThreshold = threshold
+	anomalyDays      = days
+
+	if anomalyThreshold <= 0 {
+		anomalyThreshold = DefaultAnomalyThreshold
+	}
+
+	if anomalyDays <= 0 {
+		anomalyDays = DefaultAnomalyDays
+	}
+}
+
+//=============================================================================
+//--- Compares the new value with the average of the closest days already in
+//--- the history. Returns a review if the change exceeds the threshold
+
+func checkAnomaly(cur *db.Currency, date datatype.IntDate, value float64) (*db.CurrencyReview, error) {
+	reference, err := getReferenceValue(cur, date)
+	if err != nil {
+		slog.Error("checkAnomaly: Cannot retrieve reference value", "currency", cur.Code, "date", date, "error", err)
+		return nil, err
+	}
+
+	//--- No history yet: nothing to compare with
+	if reference == 0 && value > 0 {
+		return nil, nil
+	}
+
+	change := -100.0
+	if reference != 0 {
+		change = (value - reference) / reference * 100
+	}
+
+	if value > 0 && math.Abs(change) <= anomalyThreshold {
+		return nil, nil
+	}
+
+	slog.Warn("checkAnomaly: Suspicious currency value held for review", "currency", cur.Code, "date", date, "value", value, "reference", reference, "change", change)
+
+	return &db.CurrencyReview{
+		CurrencyId    : cur.Id,
+		Date          : date,
+		Value         : value,
+		ReferenceValue: reference,
+		Change        : change,
+		Status        : db.CurrencyReviewPending,
+	}, nil
+}
+
+//=============================================================================
+
+func getReferenceValue(cur *db.Currency, date datatype.IntDate) (float64, error) {
+	var list *[]db.CurrencyHistory
+
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		var err error
+		list, err = db.GetCurrencyHistoryBefore(tx, cur.Id, date, anomalyDays)
+		if err != nil || len(*list) > 0 {
+			return err
+		}
+
+		//--- When going back in time, the closest days are the following ones
+		list, err = db.GetCurrencyHistoryAfter(tx, cur.Id, date, anomalyDays)
+		return err
+	})
+
+	if err != nil || len(*list) == 0 {
+		return 0, err
+	}
+
+	sum := 0.0
+	for _, ch := range *list {
+		sum += ch.Value
+	}
+
+	return sum / float64(len(*list)), nil
+}
+
+//=============================================================================
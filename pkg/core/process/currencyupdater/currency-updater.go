@@ -38,7 +38,7 @@ import (
 //=============================================================================
 
 const (
-	BaseCurrency = "USD"
+	BaseCurrency = db.BaseCurrency
 )
 
 var baseUrl string
@@ -50,6 +50,8 @@ func Init(cfg *app.Config) {
 	baseUrl = cfg.Provider.Currency.BaseUrl
 	apiKey  = cfg.Provider.Currency.ApiKey
 
+	initAnomalyDetector(cfg.Provider.Currency.AnomalyThreshold, cfg.Provider.Currency.AnomalyDays)
+
 	ticker := time.NewTicker(45 * time.Minute)
 
 	go func() {
@@ -73,17 +75,18 @@ func run() {
 		cur := currencies[0]
 
 		var history []*db.CurrencyHistory
+		var reviews []*db.CurrencyReview
 
 		if cur.LastDate.IsNil(){
-			history,err = latestUpdate(currencies, datatype.Today(time.UTC).AddDays(-1))
+			history,reviews,err = latestUpdate(currencies, datatype.Today(time.UTC).AddDays(-1))
 		} else if newLatestDay(cur) {
-			history,err = latestUpdate(currencies, cur.LastDate.AddDays(1))
+			history,reviews,err = latestUpdate(currencies, cur.LastDate.AddDays(1))
 		} else if !cur.HistoryEnded {
-			history,err = dateUpdate(currencies, cur.FirstDate.AddDays(-1))
+			history,reviews,err = dateUpdate(currencies, cur.FirstDate.AddDays(-1))
 		}
 
 		if err == nil {
-			err = saveCurrenciesAndHistory(currencies, history)
+			err = saveCurrenciesAndHistory(currencies, history, reviews)
 		}
 	}
 
@@ -123,15 +126,16 @@ func newLatestDay(cur *db.Currency) bool {
 
 //=============================================================================
 
-func latestUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.CurrencyHistory,error) {
+func latestUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.CurrencyHistory,[]*db.CurrencyReview,error) {
 	fcc := NewFreeCurrencyClient(baseUrl, apiKey)
 	res,err := fcc.GetHistoricalValues(date, BaseCurrency, toList(currencies))
 	if err != nil {
 		slog.Error("latestUpdate: Cannot retrieve currencies from provider", "error", err, "date", date)
-		return nil, err
+		return nil, nil, err
 	}
 
 	var history []*db.CurrencyHistory
+	var reviews []*db.CurrencyReview
 
 	for _,cur := range currencies {
 		if cur.FirstDate.IsNil() {
@@ -141,6 +145,16 @@ func latestUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.Curre
 		value, ok := res.Currencies[cur.Code]
 		//--- Skipping BaseCurrency
 		if ok {
+			review, err := checkAnomaly(cur, date, value)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if review != nil {
+				reviews = append(reviews, review)
+				continue
+			}
+
 			cur.LastValue = value
 
 			ci := &db.CurrencyHistory{
@@ -153,20 +167,21 @@ func latestUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.Curre
 		}
 	}
 
-	return history,nil
+	return history,reviews,nil
 }
 
 //=============================================================================
 
-func dateUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.CurrencyHistory,error) {
+func dateUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.CurrencyHistory,[]*db.CurrencyReview,error) {
 	fcc := NewFreeCurrencyClient(baseUrl, apiKey)
 	res,err := fcc.GetHistoricalValues(date, BaseCurrency, toList(currencies))
 	if err != nil {
 		slog.Error("dateUpdate: Cannot retrieve currencies from provider", "error", err, "date", date)
-		return nil, err
+		return nil, nil, err
 	}
 
 	var history []*db.CurrencyHistory
+	var reviews []*db.CurrencyReview
 
 	for _,cur := range currencies {
 		cur.FirstDate    = date
@@ -175,6 +190,16 @@ func dateUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.Currenc
 		value, ok := res.Currencies[cur.Code]
 		//--- Skipping BaseCurrency
 		if ok {
+			review, err := checkAnomaly(cur, date, value)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if review != nil {
+				reviews = append(reviews, review)
+				continue
+			}
+
 			ci := &db.CurrencyHistory{
 				CurrencyId: cur.Id,
 				Date      : date,
@@ -185,12 +210,12 @@ func dateUpdate(currencies []*db.Currency, date datatype.IntDate) ([]*db.Currenc
 		}
 	}
 
-	return history,nil
+	return history,reviews,nil
 }
 
 //=============================================================================
 
-func saveCurrenciesAndHistory(currencies []*db.Currency, history []*db.CurrencyHistory) error {
+func saveCurrenciesAndHistory(currencies []*db.Currency, history []*db.CurrencyHistory, reviews []*db.CurrencyReview) error {
 	return db.RunInTransaction(func(tx *gorm.DB) error {
 		for _, cur := range currencies {
 			err := db.UpdateCurrency(tx, cur)
@@ -208,6 +233,14 @@ func saveCurrenciesAndHistory(currencies []*db.Currency, history []*db.CurrencyH
 			}
 		}
 
+		for _, cr := range reviews {
+			err := db.AddCurrencyReview(tx, cr)
+			if err != nil {
+				slog.Error("saveCurrenciesAndHistory: Cannot save currency review", "error", err)
+				return err
+			}
+		}
+
 		return nil
 	})
 }
@@ -0,0 +1,156 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package instrumentsync
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/inventory-server/pkg/app"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"github.com/tradalia/inventory-server/pkg/platform"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+var systemUrl string
+
+var driftReports      = map[uint]*business.InstrumentDriftReport{}
+var driftReportsMutex sync.RWMutex
+
+//=============================================================================
+
+func Init(cfg *app.Config) *time.Ticker {
+	systemUrl = cfg.Platform.System
+
+	ticker := time.NewTicker(6 * time.Hour)
+
+	go func() {
+		time.Sleep(30 * time.Second)
+		run()
+
+		for range ticker.C {
+			run()
+		}
+	}()
+
+	return ticker
+}
+
+//=============================================================================
+
+func GetDriftReport(connectionId uint) *business.InstrumentDriftReport {
+	driftReportsMutex.RLock()
+	defer driftReportsMutex.RUnlock()
+
+	return driftReports[connectionId]
+}
+
+//=============================================================================
+
+func SetDriftReport(report *business.InstrumentDriftReport) {
+	driftReportsMutex.Lock()
+	defer driftReportsMutex.Unlock()
+
+	driftReports[report.ConnectionId] = report
+}
+
+//=============================================================================
+
+func run() {
+	slog.Info("InstrumentSync: Starting reconciliation process")
+
+	connections, err := getConnections()
+	if err != nil {
+		slog.Error("InstrumentSync: Cannot retrieve connections", "error", err)
+		return
+	}
+
+	token, err := auth.Token()
+	if err != nil {
+		slog.Error("InstrumentSync: Cannot get authentication token", "error", err)
+		return
+	}
+
+	for _, conn := range *connections {
+		reconcile(&conn, token)
+	}
+
+	slog.Info("InstrumentSync: Ending reconciliation process")
+}
+
+//=============================================================================
+
+func getConnections() (*[]db.Connection, error) {
+	var list *[]db.Connection
+
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		var err error
+		list, err = db.GetInventoryConnections(tx)
+		return err
+	})
+
+	return list, err
+}
+
+//=============================================================================
+
+func reconcile(conn *db.Connection, token string) {
+	instruments, err := platform.FetchInstruments(systemUrl, token, conn.Username, conn.Code)
+	if err != nil {
+		slog.Error("reconcile: Cannot retrieve instruments from system adapter", "connection", conn.Code, "username", conn.Username, "error", err)
+		SetDriftReport(&business.InstrumentDriftReport{
+			ConnectionId: conn.Id,
+			CheckedAt   : time.Now(),
+			Error       : err.Error(),
+			Drifts      : []*business.ProductDrift{},
+		})
+		return
+	}
+
+	var report *business.InstrumentDriftReport
+
+	err = db.RunInTransaction(func(tx *gorm.DB) error {
+		report, err = business.ReconcileInstruments(tx, conn, instruments)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("reconcile: Cannot reconcile products", "connection", conn.Code, "username", conn.Username, "error", err)
+		return
+	}
+
+	if len(report.Drifts) > 0 {
+		slog.Warn("reconcile: Products drifted from remote instruments", "connection", conn.Code, "username", conn.Username, "drifts", len(report.Drifts))
+	}
+
+	SetDriftReport(report)
+}
+
+//=============================================================================
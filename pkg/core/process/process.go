@@ -27,14 +27,18 @@ package process
 import (
 	"github.com/tradalia/inventory-server/pkg/app"
 	"github.com/tradalia/inventory-server/pkg/core/process/agentscanner"
+	"github.com/tradalia/inventory-server/pkg/core/process/connectionwatcher"
 	"github.com/tradalia/inventory-server/pkg/core/process/currencyupdater"
+	"github.com/tradalia/inventory-server/pkg/core/process/instrumentsync"
 )
 
 //=============================================================================
 
 func Init(cfg *app.Config) {
-	agentscanner   .Init(cfg)
-	currencyupdater.Init(cfg)
+	agentscanner     .Init(cfg)
+	connectionwatcher.Init(cfg)
+	currencyupdater  .Init(cfg)
+	instrumentsync   .Init(cfg)
 }
 
 //=============================================================================
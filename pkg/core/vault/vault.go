@@ -0,0 +1,281 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/tradalia/inventory-server/pkg/app"
+)
+
+//=============================================================================
+//===
+//=== Envelope encryption: each value is encrypted with a random data key,
+//=== which is in turn encrypted with the active master key. Values have the
+//=== format:
+//===
+//===    enc:v1:<key id>:<encrypted data key>:<encrypted value>
+//===
+//=== Rotating the master key only requires to re-encrypt the data keys
+//===
+//=============================================================================
+
+const prefix = "enc:v1:"
+
+//=============================================================================
+
+var masterKeys map[string][]byte
+var activeKey  string
+
+//=============================================================================
+
+func Init(cfg *app.Encryption) error {
+	masterKeys = map[string][]byte{}
+	activeKey  = cfg.ActiveKey
+
+	for _, ek := range cfg.Keys {
+		if ek.Id == "" || strings.Contains(ek.Id, ":") {
+			return errors.New("Invalid encryption key id: '"+ ek.Id +"'")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(ek.Key)
+		if err != nil {
+			return errors.New("Invalid encryption key '"+ ek.Id +"': "+ err.Error())
+		}
+
+		if len(key) != 32 {
+			return errors.New("Encryption key '"+ ek.Id +"' must be 32 bytes long")
+		}
+
+		masterKeys[ek.Id] = key
+	}
+
+	if activeKey == "" {
+		slog.Warn("Vault: No active encryption key. Secrets will be stored in plain text")
+		return nil
+	}
+
+	if _, ok := masterKeys[activeKey]; !ok {
+		return errors.New("Active encryption key not found: "+ activeKey)
+	}
+
+	slog.Info("Vault: Encryption keys loaded", "keys", len(masterKeys), "activeKey", activeKey)
+	return nil
+}
+
+//=============================================================================
+
+func IsEnabled() bool {
+	return activeKey != ""
+}
+
+//=============================================================================
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+//=============================================================================
+//--- Values already encrypted are returned as they are
+
+func Encrypt(value string) (string, error) {
+	if !IsEnabled() || IsEncrypted(value) {
+		return value, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	encValue, err := seal(dataKey, []byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	encKey, err := seal(masterKeys[activeKey], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return format(activeKey, encKey, encValue), nil
+}
+
+//=============================================================================
+//--- Values not encrypted are returned as they are
+
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	dataKey, encValue, err := openDataKey(value)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := open(dataKey, encValue)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+//=============================================================================
+
+func NeedsRotation(value string) bool {
+	if !IsEnabled() || !IsEncrypted(value) {
+		return false
+	}
+
+	keyId, _, _, err := parse(value)
+	return err == nil && keyId != activeKey
+}
+
+//=============================================================================
+//--- Only the data key is re-encrypted with the active master key
+
+func Rotate(value string) (string, error) {
+	if !NeedsRotation(value) {
+		return value, nil
+	}
+
+	dataKey, encValue, err := openDataKey(value)
+	if err != nil {
+		return "", err
+	}
+
+	encKey, err := seal(masterKeys[activeKey], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return format(activeKey, encKey, encValue), nil
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func openDataKey(value string) ([]byte, []byte, error) {
+	keyId, encKey, encValue, err := parse(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masterKey, ok := masterKeys[keyId]
+	if !ok {
+		return nil, nil, errors.New("Encryption key not found: "+ keyId)
+	}
+
+	dataKey, err := open(masterKey, encKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dataKey, encValue, nil
+}
+
+//=============================================================================
+
+func format(keyId string, encKey []byte, encValue []byte) string {
+	return prefix + keyId +":"+ base64.RawStdEncoding.EncodeToString(encKey) +":"+ base64.RawStdEncoding.EncodeToString(encValue)
+}
+
+//=============================================================================
+
+func parse(value string) (string, []byte, []byte, error) {
+	tokens := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(tokens) != 3 {
+		return "", nil, nil, errors.New("Malformed encrypted value")
+	}
+
+	encKey, err := base64.RawStdEncoding.DecodeString(tokens[1])
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	encValue, err := base64.RawStdEncoding.DecodeString(tokens[2])
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return tokens[0], encKey, encValue, nil
+}
+
+//=============================================================================
+//--- AES-GCM, with the nonce put before the encrypted data
+
+func seal(key []byte, data []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+//=============================================================================
+
+func open(key []byte, data []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("Malformed encrypted data")
+	}
+
+	nonce := data[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, data[gcm.NonceSize():], nil)
+}
+
+//=============================================================================
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//=============================================================================
@@ -0,0 +1,157 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package vault
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/tradalia/inventory-server/pkg/app"
+)
+
+//=============================================================================
+
+var key1 = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+var key2 = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+
+//=============================================================================
+
+func initVault(t *testing.T, activeKey string, keys ...app.EncryptionKey) {
+	err := Init(&app.Encryption{ ActiveKey: activeKey, Keys: keys })
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+}
+
+//=============================================================================
+
+func TestEncryptDecrypt(t *testing.T) {
+	initVault(t, "key1", app.EncryptionKey{ Id: "key1", Key: key1 })
+
+	enc, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	if !IsEncrypted(enc) || strings.Contains(enc, "secret") {
+		t.Fatalf("Value was not encrypted: %v", enc)
+	}
+
+	again, err := Encrypt(enc)
+	if err != nil || again != enc {
+		t.Fatalf("Encrypted value was encrypted again: %v", again)
+	}
+
+	plain, err := Decrypt(enc)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+
+	if plain != "secret" {
+		t.Fatalf("Expected 'secret', got '%v'", plain)
+	}
+}
+
+//=============================================================================
+
+func TestDecryptPlainValue(t *testing.T) {
+	initVault(t, "key1", app.EncryptionKey{ Id: "key1", Key: key1 })
+
+	plain, err := Decrypt("secret")
+	if err != nil || plain != "secret" {
+		t.Fatalf("Plain value was changed: %v, %v", plain, err)
+	}
+}
+
+//=============================================================================
+
+func TestEncryptDisabled(t *testing.T) {
+	initVault(t, "", app.EncryptionKey{ Id: "key1", Key: key1 })
+
+	value, err := Encrypt("secret")
+	if err != nil || value != "secret" {
+		t.Fatalf("Value was encrypted without an active key: %v, %v", value, err)
+	}
+}
+
+//=============================================================================
+
+func TestRotate(t *testing.T) {
+	initVault(t, "key1", app.EncryptionKey{ Id: "key1", Key: key1 })
+
+	enc, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	initVault(t, "key2", app.EncryptionKey{ Id: "key1", Key: key1 }, app.EncryptionKey{ Id: "key2", Key: key2 })
+
+	if !NeedsRotation(enc) {
+		t.Fatalf("Value encrypted with the old key does not need rotation")
+	}
+
+	rot, err := Rotate(enc)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if !strings.HasPrefix(rot, prefix +"key2:") || NeedsRotation(rot) {
+		t.Fatalf("Value was not rotated to the active key: %v", rot)
+	}
+
+	//--- The old key is no longer needed after the rotation
+
+	initVault(t, "key2", app.EncryptionKey{ Id: "key2", Key: key2 })
+
+	if _, err = Decrypt(enc); err == nil {
+		t.Fatalf("Value encrypted with a removed key was decrypted")
+	}
+
+	plain, err := Decrypt(rot)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+
+	if plain != "secret" {
+		t.Fatalf("Expected 'secret', got '%v'", plain)
+	}
+}
+
+//=============================================================================
+
+func TestInitInvalidKey(t *testing.T) {
+	err := Init(&app.Encryption{ ActiveKey: "key1", Keys: []app.EncryptionKey{{ Id: "key1", Key: "not-a-key" }} })
+	if err == nil {
+		t.Fatalf("Invalid key was accepted")
+	}
+
+	err = Init(&app.Encryption{ ActiveKey: "key2", Keys: []app.EncryptionKey{{ Id: "key1", Key: key1 }} })
+	if err == nil {
+		t.Fatalf("Missing active key was accepted")
+	}
+}
+
+//=============================================================================
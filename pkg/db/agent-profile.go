@@ -44,6 +44,19 @@ func GetAgentProfiles(tx *gorm.DB, filter map[string]any, offset int, limit int)
 
 //=============================================================================
 
+func CountAgentProfiles(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&AgentProfile{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetAgentProfileById(tx *gorm.DB, id uint) (*AgentProfile, error) {
 	var list []AgentProfile
 	res := tx.Find(&list, id)
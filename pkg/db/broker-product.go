@@ -31,9 +31,28 @@ import (
 
 //=============================================================================
 
-func GetBrokerProducts(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]BrokerProductFull, error) {
+var BrokerProductQueryFields = QueryFields{
+	Alias : "bp",
+	Search: []string{ "bp.symbol", "bp.name" },
+	Fields: map[string]QueryField{
+		"symbol"         : { Column: "bp.symbol",       Type: FieldString },
+		"name"           : { Column: "bp.name",         Type: FieldString },
+		"marketType"     : { Column: "bp.market_type",  Type: FieldString },
+		"productType"    : { Column: "bp.product_type", Type: FieldString },
+		"createdAt"      : { Column: "bp.created_at",   Type: FieldDate   },
+		"updatedAt"      : { Column: "bp.updated_at",   Type: FieldDate   },
+		"exchangeCode"   : { Column: "(SELECT xe.code FROM exchange xe WHERE xe.id = bp.exchange_id)",     Type: FieldString },
+		"connectionCode" : { Column: "(SELECT xc.code FROM connection xc WHERE xc.id = bp.connection_id)", Type: FieldString },
+	},
+}
+
+//=============================================================================
+
+func GetBrokerProducts(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]BrokerProductFull, error) {
 	var list []BrokerProductFull
-	res := tx.Where(filter).Offset(offset).Limit(limit).Find(&list)
+	query := tx.Table("broker_product bp")
+
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -44,15 +63,15 @@ func GetBrokerProducts(tx *gorm.DB, filter map[string]any, offset int, limit int
 
 //=============================================================================
 
-func GetBrokerProductsFull(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]BrokerProductFull, error) {
+func GetBrokerProductsFull(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]BrokerProductFull, error) {
 	var list []BrokerProductFull
-	query :=	"SELECT bp.*, m.code as currency_code, c.code as connection_code, c.name as connection_name, e.code as exchange_code, c.system_code as system_code " +
-				"FROM broker_product bp " +
-				"LEFT JOIN connection c on bp.connection_id = c.id " +
-				"LEFT JOIN exchange   e on bp.exchange_id   = e.id "  +
-				"LEFT JOIN currency   m on  e.currency_id   = m.id "
+	query := tx.Table("broker_product bp").
+		Select("bp.*, m.code as currency_code, c.code as connection_code, c.name as connection_name, e.code as exchange_code, c.system_code as system_code").
+		Joins("LEFT JOIN connection c on bp.connection_id = c.id").
+		Joins("LEFT JOIN exchange   e on bp.exchange_id   = e.id").
+		Joins("LEFT JOIN currency   m on  e.currency_id   = m.id")
 
-	res := tx.Raw(query).Where(filter).Offset(offset).Limit(limit).Find(&list)
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -63,6 +82,19 @@ func GetBrokerProductsFull(tx *gorm.DB, filter map[string]any, offset int, limit
 
 //=============================================================================
 
+func CountBrokerProducts(tx *gorm.DB, q *ListQuery) (int64, error) {
+	var total int64
+	res := q.Where(tx.Table("broker_product bp")).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetBrokerProductById(tx *gorm.DB, id uint) (*BrokerProduct, error) {
 	var list []BrokerProduct
 	res := tx.Find(&list, id)
@@ -80,6 +112,58 @@ func GetBrokerProductById(tx *gorm.DB, id uint) (*BrokerProduct, error) {
 
 //=============================================================================
 
+func GetBrokerProductsBySymbol(tx *gorm.DB, username string, symbol string) (*[]BrokerProduct, error) {
+	var list []BrokerProduct
+	res := tx.Find(&list, "username = ? and symbol = ?", username, symbol)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetBrokerProductsByCatalogProductId(tx *gorm.DB, id uint) (*[]BrokerProduct, error) {
+	var list []BrokerProduct
+	res := tx.Where("catalog_product_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetBrokerProductsByExchangeId(tx *gorm.DB, id uint) (*[]BrokerProduct, error) {
+	var list []BrokerProduct
+	res := tx.Where("exchange_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetBrokerProductsByConnectionId(tx *gorm.DB, id uint) (*[]BrokerProduct, error) {
+	var list []BrokerProduct
+	res := tx.Where("connection_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
 func AddBrokerProduct(tx *gorm.DB, pb *BrokerProduct) error {
 	return tx.Create(pb).Error
 }
@@ -112,3 +196,38 @@ func GetBrokerInstrumentsByBrokerId(tx *gorm.DB, id uint) (*[]BrokerInstrument,
 }
 
 //=============================================================================
+//===
+//=== Cost history
+//===
+//=============================================================================
+
+func GetBrokerProductCosts(tx *gorm.DB, id uint) (*[]BrokerProductCost, error) {
+	var list []BrokerProductCost
+	res := tx.Where("broker_product_id = ?", id).Order("effective_date").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+//--- There is at most one entry per date: an existing one is replaced
+
+func SetBrokerProductCost(tx *gorm.DB, bpc *BrokerProductCost) error {
+	var list []BrokerProductCost
+	res := tx.Where("broker_product_id = ? AND effective_date = ?", bpc.BrokerProductId, bpc.EffectiveDate).Find(&list)
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if len(list) > 0 {
+		bpc.Id = list[0].Id
+	}
+
+	return tx.Save(bpc).Error
+}
+
+//=============================================================================
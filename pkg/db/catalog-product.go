@@ -0,0 +1,110 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetCatalogProducts(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]CatalogProduct, error) {
+	var list []CatalogProduct
+	res := tx.Where(filter).Order("symbol").Offset(offset).Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountCatalogProducts(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&CatalogProduct{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetCatalogProductById(tx *gorm.DB, id uint) (*CatalogProduct, error) {
+	var list []CatalogProduct
+	res := tx.Find(&list, id)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func GetCatalogProductBySymbol(tx *gorm.DB, exchangeId uint, symbol string) (*CatalogProduct, error) {
+	var list []CatalogProduct
+	res := tx.Find(&list, "exchange_id = ? and symbol = ?", exchangeId, symbol)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddCatalogProduct(tx *gorm.DB, cp *CatalogProduct) error {
+	return tx.Create(cp).Error
+}
+
+//=============================================================================
+
+func UpdateCatalogProduct(tx *gorm.DB, cp *CatalogProduct) error {
+	return tx.Save(cp).Error
+}
+
+//=============================================================================
+
+func DeleteCatalogProduct(tx *gorm.DB, id uint) error {
+	return tx.Delete(&CatalogProduct{}, id).Error
+}
+
+//=============================================================================
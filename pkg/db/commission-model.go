@@ -0,0 +1,109 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetCommissionModelByBrokerProductId(tx *gorm.DB, id uint) (*CommissionModel, error) {
+	var list []CommissionModel
+	res := tx.Where("broker_product_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func SetCommissionModel(tx *gorm.DB, cm *CommissionModel) error {
+	return tx.Save(cm).Error
+}
+
+//=============================================================================
+
+func DeleteCommissionModel(tx *gorm.DB, id uint) error {
+	err := DeleteCommissionTiers(tx, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(&CommissionModel{}, id).Error
+}
+
+//=============================================================================
+//===
+//=== Tiers
+//===
+//=============================================================================
+
+func GetCommissionTiers(tx *gorm.DB, id uint) (*[]CommissionTier, error) {
+	var list []CommissionTier
+	res := tx.Where("commission_model_id = ?", id).Order("from_contracts").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func SetCommissionTiers(tx *gorm.DB, id uint, tiers []CommissionTier) error {
+	err := DeleteCommissionTiers(tx, id)
+	if err != nil {
+		return err
+	}
+
+	for i := range tiers {
+		tiers[i].Id                = 0
+		tiers[i].CommissionModelId = id
+	}
+
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	return tx.Create(&tiers).Error
+}
+
+//=============================================================================
+
+func DeleteCommissionTiers(tx *gorm.DB, id uint) error {
+	return tx.Delete(&CommissionTier{}, "commission_model_id = ?", id).Error
+}
+
+//=============================================================================
@@ -25,15 +25,38 @@ THE SOFTWARE.
 package db
 
 import (
+	"time"
+
 	"github.com/tradalia/core/req"
 	"gorm.io/gorm"
 )
 
 //=============================================================================
 
-func GetConnections(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]Connection, error) {
+var ConnectionQueryFields = QueryFields{
+	Alias : "c",
+	Search: []string{ "c.code", "c.name" },
+	Fields: map[string]QueryField{
+		"code"              : { Column: "c.code",               Type: FieldString },
+		"name"              : { Column: "c.name",               Type: FieldString },
+		"systemCode"        : { Column: "c.system_code",        Type: FieldString },
+		"connected"         : { Column: "c.connected",          Type: FieldBool   },
+		"supportsData"      : { Column: "c.supports_data",      Type: FieldBool   },
+		"supportsBroker"    : { Column: "c.supports_broker",    Type: FieldBool   },
+		"supportsInventory" : { Column: "c.supports_inventory", Type: FieldBool   },
+		"lastConnectedAt"   : { Column: "c.last_connected_at",  Type: FieldDate   },
+		"createdAt"         : { Column: "c.created_at",         Type: FieldDate   },
+		"updatedAt"         : { Column: "c.updated_at",         Type: FieldDate   },
+	},
+}
+
+//=============================================================================
+
+func GetConnections(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]Connection, error) {
 	var list []Connection
-	res := tx.Where(filter).Offset(offset).Limit(limit).Find(&list)
+	query := tx.Table("connection c")
+
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -44,6 +67,19 @@ func GetConnections(tx *gorm.DB, filter map[string]any, offset int, limit int) (
 
 //=============================================================================
 
+func CountConnections(tx *gorm.DB, q *ListQuery) (int64, error) {
+	var total int64
+	res := q.Where(tx.Table("connection c")).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetConnectionById(tx *gorm.DB, id uint) (*Connection, error) {
 	var list []Connection
 	res := tx.Find(&list, id)
@@ -78,6 +114,19 @@ func GetConnectionByCode(tx *gorm.DB, user, code string) (*Connection, error) {
 
 //=============================================================================
 
+func GetInventoryConnections(tx *gorm.DB) (*[]Connection, error) {
+	var list []Connection
+	res := tx.Where("supports_inventory = true").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
 func AddConnection(tx *gorm.DB, conn *Connection) error {
 	return tx.Create(conn).Error
 }
@@ -90,18 +139,87 @@ func UpdateConnection(tx *gorm.DB, conn *Connection) error {
 
 //=============================================================================
 
+func GetConnectionsToDisconnect(tx *gorm.DB) (*[]Connection, error) {
+	var list []Connection
+	res := tx.Where("supports_multiple_data = false AND (connected = true OR connecting_at IS NOT NULL)").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetConnectionsConnectingBefore(tx *gorm.DB, date time.Time) (*[]Connection, error) {
+	var list []Connection
+	res := tx.Where("connecting_at < ?", date).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
 func DisconnectAll(tx *gorm.DB) error {
 	return tx.Model(&Connection{}).
 		Where("supports_multiple_data = false").
-		Update("connected", false).Error
+		Updates(map[string]any{
+			"connected"    : false,
+			"connecting_at": nil,
+		}).Error
 }
 
 //=============================================================================
 
-func SetConnectionStatus(tx *gorm.DB, user, code string, flag bool) error {
+func SetConnectionStatus(tx *gorm.DB, conn *Connection) error {
 	return tx.Model(&Connection{}).
-		Where("username = ? AND code = ?", user, code).
-		Update("connected", flag).Error
+		Where("id = ?", conn.Id).
+		Updates(map[string]any{
+			"connected"        : conn.Connected,
+			"last_connected_at": conn.LastConnectedAt,
+			"connecting_at"    : conn.ConnectingAt,
+		}).Error
+}
+
+//=============================================================================
+
+func AddConnectionStatusChange(tx *gorm.DB, csc *ConnectionStatusChange) error {
+	return tx.Create(csc).Error
+}
+
+//=============================================================================
+
+func GetConnectionStatusChanges(tx *gorm.DB, id uint, from time.Time) (*[]ConnectionStatusChange, error) {
+	var list []ConnectionStatusChange
+	res := tx.Where("connection_id = ? AND created_at >= ?", id, from).Order("created_at, id").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetLastConnectionStatusChangeBefore(tx *gorm.DB, id uint, date time.Time) (*ConnectionStatusChange, error) {
+	var list []ConnectionStatusChange
+	res := tx.Where("connection_id = ? AND created_at < ?", id, date).Order("created_at desc, id desc").Limit(1).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
 }
 
 //=============================================================================
@@ -25,6 +25,7 @@ THE SOFTWARE.
 package db
 
 import (
+	"github.com/tradalia/core/datatype"
 	"github.com/tradalia/core/req"
 	"gorm.io/gorm"
 )
@@ -61,6 +62,23 @@ func GetCurrencyById(tx *gorm.DB, id uint) (*Currency, error) {
 
 //=============================================================================
 
+func GetCurrencyByCode(tx *gorm.DB, code string) (*Currency, error) {
+	var list []Currency
+	res := tx.Where("code = ?", code).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
 func UpdateCurrency(tx *gorm.DB, c *Currency) error {
 	return tx.Save(c).Error
 }
@@ -72,3 +90,169 @@ func AddCurrencyHistory(tx *gorm.DB, ci *CurrencyHistory) error {
 }
 
 //=============================================================================
+//--- Returns the closest value on or before the given date. If the history
+//--- starts after the date, the first available value is returned
+
+func GetCurrencyHistoryAt(tx *gorm.DB, currencyId uint, date datatype.IntDate) (*CurrencyHistory, error) {
+	var list []CurrencyHistory
+	res := tx.Where("currency_id = ? AND date <= ?", currencyId, date).Order("date desc").Limit(1).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 0 {
+		res = tx.Where("currency_id = ? AND date > ?", currencyId, date).Order("date").Limit(1).Find(&list)
+
+		if res.Error != nil {
+			return nil, req.NewServerErrorByError(res.Error)
+		}
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func GetCurrencyHistoryBefore(tx *gorm.DB, currencyId uint, date datatype.IntDate, limit int) (*[]CurrencyHistory, error) {
+	var list []CurrencyHistory
+	res := tx.Where("currency_id = ? AND date < ?", currencyId, date).Order("date desc").Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetCurrencyHistoryAfter(tx *gorm.DB, currencyId uint, date datatype.IntDate, limit int) (*[]CurrencyHistory, error) {
+	var list []CurrencyHistory
+	res := tx.Where("currency_id = ? AND date > ?", currencyId, date).Order("date").Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+//--- History is paged backwards from the most recent date. A nil 'before' date
+//--- starts from the last available value
+
+func GetCurrencyHistory(tx *gorm.DB, currencyId uint, from datatype.IntDate, to datatype.IntDate, before datatype.IntDate, limit int) (*[]CurrencyHistory, error) {
+	var list []CurrencyHistory
+	query := currencyHistoryQuery(tx, currencyId, from, to)
+
+	if !before.IsNil() {
+		query = query.Where("date < ?", before)
+	}
+
+	res := query.Order("date desc").Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountCurrencyHistory(tx *gorm.DB, currencyId uint, from datatype.IntDate, to datatype.IntDate) (int64, error) {
+	var total int64
+	res := currencyHistoryQuery(tx, currencyId, from, to).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+//===
+//=== Currency reviews
+//===
+//=============================================================================
+
+func GetCurrencyReviews(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]CurrencyReview, error) {
+	var list []CurrencyReview
+	res := tx.Where(filter).Order("date").Offset(offset).Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountCurrencyReviews(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&CurrencyReview{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetCurrencyReviewById(tx *gorm.DB, id uint) (*CurrencyReview, error) {
+	var list []CurrencyReview
+	res := tx.Find(&list, id)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddCurrencyReview(tx *gorm.DB, cr *CurrencyReview) error {
+	return tx.Create(cr).Error
+}
+
+//=============================================================================
+
+func UpdateCurrencyReview(tx *gorm.DB, cr *CurrencyReview) error {
+	return tx.Save(cr).Error
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func currencyHistoryQuery(tx *gorm.DB, currencyId uint, from datatype.IntDate, to datatype.IntDate) *gorm.DB {
+	query := tx.Model(&CurrencyHistory{}).Where("currency_id = ?", currencyId)
+
+	if !from.IsNil() {
+		query = query.Where("date >= ?", from)
+	}
+
+	if !to.IsNil() {
+		query = query.Where("date <= ?", to)
+	}
+
+	return query
+}
+
+//=============================================================================
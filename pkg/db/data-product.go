@@ -31,9 +31,28 @@ import (
 
 //=============================================================================
 
-func GetDataProducts(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]DataProductFull, error) {
+var DataProductQueryFields = QueryFields{
+	Alias : "dp",
+	Search: []string{ "dp.symbol", "dp.name" },
+	Fields: map[string]QueryField{
+		"symbol"         : { Column: "dp.symbol",       Type: FieldString },
+		"name"           : { Column: "dp.name",         Type: FieldString },
+		"marketType"     : { Column: "dp.market_type",  Type: FieldString },
+		"productType"    : { Column: "dp.product_type", Type: FieldString },
+		"createdAt"      : { Column: "dp.created_at",   Type: FieldDate   },
+		"updatedAt"      : { Column: "dp.updated_at",   Type: FieldDate   },
+		"exchangeCode"   : { Column: "(SELECT xe.code FROM exchange xe WHERE xe.id = dp.exchange_id)",     Type: FieldString },
+		"connectionCode" : { Column: "(SELECT xc.code FROM connection xc WHERE xc.id = dp.connection_id)", Type: FieldString },
+	},
+}
+
+//=============================================================================
+
+func GetDataProducts(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]DataProductFull, error) {
 	var list []DataProductFull
-	res := tx.Where(filter).Offset(offset).Limit(limit).Find(&list)
+	query := tx.Table("data_product dp")
+
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -44,14 +63,14 @@ func GetDataProducts(tx *gorm.DB, filter map[string]any, offset int, limit int)
 
 //=============================================================================
 
-func GetDataProductsFull(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]DataProductFull, error) {
+func GetDataProductsFull(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]DataProductFull, error) {
 	var list []DataProductFull
-	query :=	"SELECT dp.*, c.code as connection_code, c.name as connection_name, c.system_code as system_code, e.code as exchange_code " +
-				"FROM data_product dp " +
-				"LEFT JOIN connection c on dp.connection_id = c.id "+
-				"LEFT JOIN exchange   e on dp.exchange_id   = e.id"
+	query := tx.Table("data_product dp").
+		Select("dp.*, c.code as connection_code, c.name as connection_name, c.system_code as system_code, e.code as exchange_code").
+		Joins("LEFT JOIN connection c on dp.connection_id = c.id").
+		Joins("LEFT JOIN exchange   e on dp.exchange_id   = e.id")
 
-	res := tx.Raw(query).Where(filter).Offset(offset).Limit(limit).Find(&list)
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -62,6 +81,19 @@ func GetDataProductsFull(tx *gorm.DB, filter map[string]any, offset int, limit i
 
 //=============================================================================
 
+func CountDataProducts(tx *gorm.DB, q *ListQuery) (int64, error) {
+	var total int64
+	res := q.Where(tx.Table("data_product dp")).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetDataProductById(tx *gorm.DB, id uint) (*DataProduct, error) {
 	var list []DataProduct
 	res := tx.Find(&list, id)
@@ -79,6 +111,58 @@ func GetDataProductById(tx *gorm.DB, id uint) (*DataProduct, error) {
 
 //=============================================================================
 
+func GetDataProductsBySymbol(tx *gorm.DB, username string, symbol string) (*[]DataProduct, error) {
+	var list []DataProduct
+	res := tx.Find(&list, "username = ? and symbol = ?", username, symbol)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetDataProductsByCatalogProductId(tx *gorm.DB, id uint) (*[]DataProduct, error) {
+	var list []DataProduct
+	res := tx.Where("catalog_product_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetDataProductsByExchangeId(tx *gorm.DB, id uint) (*[]DataProduct, error) {
+	var list []DataProduct
+	res := tx.Where("exchange_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetDataProductsByConnectionId(tx *gorm.DB, id uint) (*[]DataProduct, error) {
+	var list []DataProduct
+	res := tx.Where("connection_id = ?", id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
 func AddDataProduct(tx *gorm.DB, ts *DataProduct) error {
 	return tx.Create(ts).Error
 }
@@ -0,0 +1,104 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== Trading hours
+//===
+//=============================================================================
+
+func GetExchangeHours(tx *gorm.DB, exchangeId uint) (*[]ExchangeHours, error) {
+	var list []ExchangeHours
+	res := tx.Where("exchange_id = ?", exchangeId).Order("weekday").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func SetExchangeHours(tx *gorm.DB, exchangeId uint, list *[]ExchangeHours) error {
+	err := tx.Where("exchange_id = ?", exchangeId).Delete(&ExchangeHours{}).Error
+	if err != nil {
+		return err
+	}
+
+	if len(*list) == 0 {
+		return nil
+	}
+
+	return tx.Create(list).Error
+}
+
+//=============================================================================
+//===
+//=== Holidays
+//===
+//=============================================================================
+
+func GetExchangeHolidays(tx *gorm.DB, exchangeId uint, from, to datatype.IntDate) (*[]ExchangeHoliday, error) {
+	var list []ExchangeHoliday
+	res := tx.Where("exchange_id = ? AND date >= ? AND date <= ?", exchangeId, from, to).Order("date").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func SetExchangeHoliday(tx *gorm.DB, h *ExchangeHoliday) error {
+	err := tx.Where("exchange_id = ? AND date = ?", h.ExchangeId, h.Date).Delete(&ExchangeHoliday{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(h).Error
+}
+
+//=============================================================================
+
+func DeleteExchangeCalendar(tx *gorm.DB, exchangeId uint) error {
+	err := tx.Where("exchange_id = ?", exchangeId).Delete(&ExchangeHoliday{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Where("exchange_id = ?", exchangeId).Delete(&ExchangeHours{}).Error
+}
+
+//=============================================================================
@@ -60,3 +60,38 @@ func GetExchangeById(tx *gorm.DB, id uint) (*Exchange, error) {
 }
 
 //=============================================================================
+
+func GetExchangeByCode(tx *gorm.DB, code string) (*Exchange, error) {
+	var list []Exchange
+	res := tx.Where("code = ?", code).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddExchange(tx *gorm.DB, e *Exchange) error {
+	return tx.Create(e).Error
+}
+
+//=============================================================================
+
+func UpdateExchange(tx *gorm.DB, e *Exchange) error {
+	return tx.Save(e).Error
+}
+
+//=============================================================================
+
+func DeleteExchange(tx *gorm.DB, id uint) error {
+	return tx.Delete(&Exchange{}, id).Error
+}
+
+//=============================================================================
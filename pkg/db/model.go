@@ -39,6 +39,11 @@ type Common struct {
 }
 
 //=============================================================================
+//--- All values in currency_history are expressed against this currency
+
+const BaseCurrency = "USD"
+
+//-----------------------------------------------------------------------------
 
 type Currency struct {
 	Id           uint              `json:"id"`
@@ -62,6 +67,30 @@ type CurrencyHistory struct {
 
 //=============================================================================
 
+type CurrencyReviewStatus string
+
+const (
+	CurrencyReviewPending    = "pending"
+	CurrencyReviewAccepted   = "accepted"
+	CurrencyReviewOverridden = "overridden"
+)
+
+//-----------------------------------------------------------------------------
+
+type CurrencyReview struct {
+	Common
+	CurrencyId      uint                 `json:"currencyId"`
+	Date            datatype.IntDate     `json:"date"`
+	Value           float64              `json:"value"`
+	ReferenceValue  float64              `json:"referenceValue"`
+	Change          float64              `json:"change"`
+	Status          CurrencyReviewStatus `json:"status"`
+	FinalValue      float64              `json:"finalValue"`
+	ReviewedBy      string               `json:"reviewedBy"`
+}
+
+//=============================================================================
+
 type Exchange struct {
 	Id         uint   `json:"id"`
 	CurrencyId uint   `json:"currencyId"`
@@ -73,19 +102,93 @@ type Exchange struct {
 
 //=============================================================================
 
+type ExchangeHours struct {
+	Id          uint  `json:"id" gorm:"primaryKey"`
+	ExchangeId  uint  `json:"exchangeId"`
+	Weekday     int   `json:"weekday"`
+	OpenTime    int   `json:"openTime"`
+	CloseTime   int   `json:"closeTime"`
+}
+
+//=============================================================================
+
+type HolidayType string
+
+const (
+	HolidayTypeClosed     = "closed"
+	HolidayTypeEarlyClose = "early-close"
+)
+
+//-----------------------------------------------------------------------------
+
+type ExchangeHoliday struct {
+	Id          uint              `json:"id" gorm:"primaryKey"`
+	ExchangeId  uint              `json:"exchangeId"`
+	Date        datatype.IntDate  `json:"date"`
+	Name        string            `json:"name"`
+	Type        HolidayType       `json:"type"`
+	CloseTime   int               `json:"closeTime"`
+}
+
+//=============================================================================
+
 type Connection struct {
 	Common
-	Username             string `json:"username"`
-	Code                 string `json:"code"`
-	Name                 string `json:"name"`
-	SystemCode           string `json:"systemCode"`
-	SystemName           string `json:"systemName"`
-	SystemConfigParams   string `json:"systemConfigParams"`
-	Connected            bool   `json:"connected"`
-	SupportsData         bool   `json:"supportsData"`
-	SupportsBroker       bool   `json:"supportsBroker"`
-	SupportsMultipleData bool   `json:"supportsMultipleData"`
-	SupportsInventory    bool   `json:"supportsInventory"`
+	Username             string     `json:"username"`
+	Code                 string     `json:"code"`
+	Name                 string     `json:"name"`
+	SystemCode           string     `json:"systemCode"`
+	SystemName           string     `json:"systemName"`
+	SystemConfigParams   string     `json:"systemConfigParams"`
+	Connected            bool       `json:"connected"`
+	SupportsData         bool       `json:"supportsData"`
+	SupportsBroker       bool       `json:"supportsBroker"`
+	SupportsMultipleData bool       `json:"supportsMultipleData"`
+	SupportsInventory    bool       `json:"supportsInventory"`
+	LastConnectedAt      *time.Time `json:"lastConnectedAt"`
+	ConnectingAt         *time.Time `json:"connectingAt"`
+}
+
+//-----------------------------------------------------------------------------
+
+type ConnectionStatus string
+
+const (
+	ConnectionStatusDisconnected = "disconnected"
+	ConnectionStatusConnecting   = "connecting"
+	ConnectionStatusConnected    = "connected"
+)
+
+const (
+	ConnectionSourceSystemAdapter  = "system-adapter"
+	ConnectionSourceAdapterRestart = "adapter-restart"
+	ConnectionSourceUser           = "user"
+	ConnectionSourceTimeout        = "timeout"
+)
+
+//-----------------------------------------------------------------------------
+
+type ConnectionStatusChange struct {
+	Id           uint             `json:"id" gorm:"primaryKey"`
+	ConnectionId uint             `json:"connectionId"`
+	Status       ConnectionStatus `json:"status"`
+	Source       string           `json:"source"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+//=============================================================================
+
+type CatalogProduct struct {
+	Common
+	ExchangeId   uint    `json:"exchangeId"`
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name"`
+	PointValue   float32 `json:"pointValue"`
+	Increment    float64 `json:"increment"`
+	Months       string  `json:"months"`
+	MarginValue  float32 `json:"marginValue"`
+	MarketType   string  `json:"marketType"`
+	ProductType  string  `json:"productType"`
 }
 
 //=============================================================================
@@ -102,15 +205,16 @@ const (
 
 type DataProduct struct {
 	Common
-	ConnectionId    uint          `json:"connectionId"`
-	ExchangeId      uint          `json:"exchangeId"`
-	Username        string        `json:"username"`
-	Symbol          string        `json:"symbol"`
-	Name            string        `json:"name"`
-	MarketType      string        `json:"marketType"`
-	ProductType     string        `json:"productType"`
-	Months          string        `json:"months"`
-	RolloverTrigger DPRollTrigger `json:"rolloverTrigger"`
+	ConnectionId     uint          `json:"connectionId"`
+	ExchangeId       uint          `json:"exchangeId"`
+	Username         string        `json:"username"`
+	Symbol           string        `json:"symbol"`
+	Name             string        `json:"name"`
+	MarketType       string        `json:"marketType"`
+	ProductType      string        `json:"productType"`
+	Months           string        `json:"months"`
+	RolloverTrigger  DPRollTrigger `json:"rolloverTrigger"`
+	CatalogProductId *uint         `json:"catalogProductId"`
 }
 
 //=============================================================================
@@ -138,6 +242,7 @@ type BrokerProduct struct {
 	Increment        float64  `json:"increment"`
 	MarketType       string   `json:"marketType"`
 	ProductType      string   `json:"productType"`
+	CatalogProductId *uint    `json:"catalogProductId"`
 }
 
 //=============================================================================
@@ -149,6 +254,83 @@ type BrokerProductFull struct {
 	ConnectionName  string  `json:"connectionName,omitempty"`
 	SystemCode      string  `json:"systemCode,omitempty"`
 	ExchangeCode    string  `json:"exchangeCode,omitempty"`
+
+	ReportingCurrencyCode      string  `json:"reportingCurrencyCode,omitempty"     gorm:"-"`
+	ReportingCostPerOperation  float32 `json:"reportingCostPerOperation,omitempty" gorm:"-"`
+	ReportingMarginValue       float32 `json:"reportingMarginValue,omitempty"      gorm:"-"`
+}
+
+//=============================================================================
+
+//--- Margin and commission are effective from their date until the next entry
+
+type BrokerProductCost struct {
+	Id               uint              `json:"id" gorm:"primaryKey"`
+	BrokerProductId  uint              `json:"brokerProductId"`
+	EffectiveDate    datatype.IntDate  `json:"effectiveDate"`
+	MarginValue      float32           `json:"marginValue"`
+	CostPerOperation float32           `json:"costPerOperation"`
+}
+
+//=============================================================================
+
+type CommissionType string
+
+const (
+	CommissionTypeFixed       = "fixed"
+	CommissionTypePerContract = "per-contract"
+	CommissionTypePercentage  = "percentage"
+	CommissionTypeTiered      = "tiered"
+)
+
+//-----------------------------------------------------------------------------
+//--- Exchange fees are charged per contract on top of the commission, then
+//--- the total is bound by the minimum and maximum (when not zero)
+
+type CommissionModel struct {
+	Common
+	BrokerProductId  uint            `json:"brokerProductId"`
+	Type             CommissionType  `json:"type"`
+	CurrencyId       uint            `json:"currencyId"`
+	Fixed            float64         `json:"fixed"`
+	PerContract      float64         `json:"perContract"`
+	Percentage       float64         `json:"percentage"`
+	ExchangeFee      float64         `json:"exchangeFee"`
+	MinCommission    float64         `json:"minCommission"`
+	MaxCommission    float64         `json:"maxCommission"`
+}
+
+//-----------------------------------------------------------------------------
+//--- Each tier applies to the contracts from its start up to the next tier
+
+type CommissionTier struct {
+	Id                uint     `json:"id" gorm:"primaryKey"`
+	CommissionModelId uint     `json:"commissionModelId"`
+	FromContracts     int      `json:"fromContracts"`
+	PerContract       float64  `json:"perContract"`
+}
+
+//=============================================================================
+
+//--- Links the data feed of an instrument to the product used to trade it.
+//--- Broker prices are data prices times the multiplier
+
+type ProductMapping struct {
+	Common
+	Username         string   `json:"username"`
+	DataProductId    uint     `json:"dataProductId"`
+	BrokerProductId  uint     `json:"brokerProductId"`
+	PriceMultiplier  float64  `json:"priceMultiplier"`
+	BrokerSymbol     string   `json:"brokerSymbol"`
+}
+
+//-----------------------------------------------------------------------------
+
+type MappedBrokerProduct struct {
+	BrokerProduct
+	MappingId        uint     `json:"mappingId"`
+	PriceMultiplier  float64  `json:"priceMultiplier"`
+	BrokerSymbol     string   `json:"brokerSymbol"`
 }
 
 //=============================================================================
@@ -172,6 +354,20 @@ type TradingSession struct {
 
 //=============================================================================
 
+type TSStatus string
+
+const (
+	TSStatusDraft         = "draft"
+	TSStatusInDevelopment = "in-development"
+	TSStatusFinalized     = "finalized"
+	TSStatusIncubation    = "incubation"
+	TSStatusLive          = "live"
+	TSStatusPaused        = "paused"
+	TSStatusRetired       = "retired"
+)
+
+//-----------------------------------------------------------------------------
+
 type TradingSystem struct {
 	Common
 	Username          string           `json:"username"`
@@ -185,7 +381,10 @@ type TradingSystem struct {
 	Overnight         bool             `json:"overnight"`
 	Tags              string           `json:"tags"`
 	ExternalRef       string           `json:"externalRef"`
+	Status            TSStatus         `json:"status"`
 	Finalized         bool             `json:"finalized"`
+	Version           int              `json:"version"`
+	ParentId          *uint            `json:"parentId"`
 	InSampleFrom      datatype.IntDate `json:"inSampleFrom"`
 	InSampleTo        datatype.IntDate `json:"inSampleTo"`
 	EngineCode        string           `json:"engineCode"`
@@ -193,6 +392,30 @@ type TradingSystem struct {
 
 //=============================================================================
 
+type TradingSystemTransition struct {
+	Id               uint      `json:"id" gorm:"primaryKey"`
+	TradingSystemId  uint      `json:"tradingSystemId"`
+	Username         string    `json:"username"`
+	FromStatus       TSStatus  `json:"fromStatus"`
+	ToStatus         TSStatus  `json:"toStatus"`
+	Reason           string    `json:"reason"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+//=============================================================================
+
+type TradingSystemVersion struct {
+	Id               uint      `json:"id" gorm:"primaryKey"`
+	TradingSystemId  uint      `json:"tradingSystemId"`
+	Version          int       `json:"version"`
+	Username         string    `json:"username"`
+	Snapshot         string    `json:"snapshot"`
+	Changes          string    `json:"changes"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+//=============================================================================
+
 type TradingSystemFull struct {
 	TradingSystem
 	DataSymbol     string `json:"dataSymbol,omitempty"`
@@ -202,6 +425,57 @@ type TradingSystemFull struct {
 
 //=============================================================================
 
+type ExternalRefDuplicate struct {
+	Username    string `json:"username"`
+	ExternalRef string `json:"externalRef"`
+	Count       int    `json:"count"`
+	Ids         string `json:"ids"`
+}
+
+//=============================================================================
+
+type Tag struct {
+	Common
+	Username  string  `json:"username"`
+	Name      string  `json:"name"`
+}
+
+//=============================================================================
+
+type TagFull struct {
+	Tag
+	Count  int  `json:"count"`
+}
+
+//=============================================================================
+
+type TradingSystemTag struct {
+	TradingSystemId  uint  `json:"tradingSystemId" gorm:"primaryKey"`
+	TagId            uint  `json:"tagId"           gorm:"primaryKey"`
+}
+
+//=============================================================================
+
+type Portfolio struct {
+	Common
+	Username    string  `json:"username"`
+	Name        string  `json:"name"`
+	CurrencyId  uint    `json:"currencyId"`
+	Live        bool    `json:"live"`
+}
+
+//=============================================================================
+
+type PortfolioItem struct {
+	Id               uint     `json:"id" gorm:"primaryKey"`
+	PortfolioId      uint     `json:"portfolioId"`
+	TradingSystemId  uint     `json:"tradingSystemId"`
+	Weight           float64  `json:"weight"`
+	Multiplier       int      `json:"multiplier"`
+}
+
+//=============================================================================
+
 type AgentProfile struct {
 	Common
 	Username     string  `json:"username"`
@@ -212,21 +486,47 @@ type AgentProfile struct {
 	ScanInterval int     `json:"scanInterval"`
 }
 
+//=============================================================================
+
+type UserPreferences struct {
+	Common
+	Username                 string  `json:"username"`
+	ReportingCurrencyId      *uint   `json:"reportingCurrencyId"`
+	DefaultExchangeId        *uint   `json:"defaultExchangeId"`
+	DefaultTradingSessionId  *uint   `json:"defaultTradingSessionId"`
+}
+
 //=============================================================================
 //===
 //=== Table names
 //===
 //=============================================================================
 
-func (Currency)         TableName() string { return "currency"          }
-func (CurrencyHistory)  TableName() string { return "currency_history"  }
-func (Exchange)         TableName() string { return "exchange"          }
-func (Connection)       TableName() string { return "connection"        }
-func (AgentProfile)     TableName() string { return "agent_profile"     }
-func (DataProduct)      TableName() string { return "data_product"      }
-func (BrokerProduct)    TableName() string { return "broker_product"    }
-func (BrokerInstrument) TableName() string { return "broker_instrument" }
-func (TradingSession)   TableName() string { return "trading_session"   }
-func (TradingSystem)    TableName() string { return "trading_system"    }
+func (Currency)                TableName() string { return "currency"                  }
+func (CurrencyHistory)         TableName() string { return "currency_history"          }
+func (CurrencyReview)          TableName() string { return "currency_review"           }
+func (Exchange)                TableName() string { return "exchange"                  }
+func (ExchangeHours)           TableName() string { return "exchange_hours"            }
+func (ExchangeHoliday)         TableName() string { return "exchange_holiday"          }
+func (Connection)              TableName() string { return "connection"                }
+func (ConnectionStatusChange)  TableName() string { return "connection_status_change"  }
+func (AgentProfile)            TableName() string { return "agent_profile"             }
+func (CatalogProduct)          TableName() string { return "catalog_product"           }
+func (DataProduct)             TableName() string { return "data_product"              }
+func (BrokerProduct)           TableName() string { return "broker_product"            }
+func (BrokerProductCost)       TableName() string { return "broker_product_cost"       }
+func (CommissionModel)         TableName() string { return "commission_model"          }
+func (CommissionTier)          TableName() string { return "commission_tier"           }
+func (ProductMapping)          TableName() string { return "product_mapping"           }
+func (BrokerInstrument)        TableName() string { return "broker_instrument"         }
+func (TradingSession)          TableName() string { return "trading_session"           }
+func (TradingSystem)           TableName() string { return "trading_system"            }
+func (TradingSystemTransition) TableName() string { return "trading_system_transition" }
+func (TradingSystemVersion)    TableName() string { return "trading_system_version"    }
+func (Portfolio)               TableName() string { return "portfolio"                 }
+func (PortfolioItem)           TableName() string { return "portfolio_item"            }
+func (Tag)                     TableName() string { return "tag"                       }
+func (TradingSystemTag)        TableName() string { return "trading_system_tag"        }
+func (UserPreferences)         TableName() string { return "user_preferences"          }
 
 //=============================================================================
@@ -0,0 +1,161 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetPortfolios(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]Portfolio, error) {
+	var list []Portfolio
+	res := tx.Where(filter).Offset(offset).Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountPortfolios(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&Portfolio{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetPortfolioById(tx *gorm.DB, id uint) (*Portfolio, error) {
+	var list []Portfolio
+	res := tx.Find(&list, id)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func GetPortfoliosByTradingSystemId(tx *gorm.DB, id uint) (*[]Portfolio, error) {
+	var list []Portfolio
+	query :=
+		"SELECT DISTINCT p.* " +
+		"FROM portfolio p " +
+		"JOIN portfolio_item pi on pi.portfolio_id = p.id " +
+		"WHERE pi.trading_system_id = ?"
+
+	res := tx.Raw(query, id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func AddPortfolio(tx *gorm.DB, p *Portfolio) error {
+	return tx.Create(p).Error
+}
+
+//=============================================================================
+
+func UpdatePortfolio(tx *gorm.DB, p *Portfolio) error {
+	return tx.Save(p).Error
+}
+
+//=============================================================================
+
+func DeletePortfolio(tx *gorm.DB, id uint) error {
+	return tx.Delete(&Portfolio{}, id).Error
+}
+
+//=============================================================================
+//===
+//=== Items
+//===
+//=============================================================================
+
+func GetPortfolioItems(tx *gorm.DB, id uint) (*[]PortfolioItem, error) {
+	var list []PortfolioItem
+	res := tx.Where("portfolio_id = ?", id).Order("id").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func SetPortfolioItems(tx *gorm.DB, id uint, items []PortfolioItem) error {
+	err := DeletePortfolioItems(tx, id)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Id          = 0
+		items[i].PortfolioId = id
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return tx.Create(&items).Error
+}
+
+//=============================================================================
+
+func DeletePortfolioItems(tx *gorm.DB, id uint) error {
+	return tx.Delete(&PortfolioItem{}, "portfolio_id = ?", id).Error
+}
+
+//=============================================================================
+
+func DeletePortfolioItemsByTradingSystemId(tx *gorm.DB, id uint) error {
+	return tx.Delete(&PortfolioItem{}, "trading_system_id = ?", id).Error
+}
+
+//=============================================================================
@@ -0,0 +1,141 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetProductMappings(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]ProductMapping, error) {
+	var list []ProductMapping
+	res := tx.Where(filter).Order("id").Offset(offset).Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountProductMappings(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&ProductMapping{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetProductMappingById(tx *gorm.DB, id uint) (*ProductMapping, error) {
+	var list []ProductMapping
+	res := tx.Find(&list, id)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func GetProductMapping(tx *gorm.DB, dataProductId uint, brokerProductId uint) (*ProductMapping, error) {
+	var list []ProductMapping
+	res := tx.Find(&list, "data_product_id = ? and broker_product_id = ?", dataProductId, brokerProductId)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func CountProductMappingsByDataProductId(tx *gorm.DB, id uint) (int64, error) {
+	var total int64
+	res := tx.Model(&ProductMapping{}).Where("data_product_id = ?", id).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetMappedBrokerProducts(tx *gorm.DB, dataProductId uint) (*[]MappedBrokerProduct, error) {
+	var list []MappedBrokerProduct
+	res := tx.Table("broker_product bp").
+		Select("bp.*, pm.id as mapping_id, pm.price_multiplier, pm.broker_symbol").
+		Joins("JOIN product_mapping pm ON pm.broker_product_id = bp.id").
+		Where("pm.data_product_id = ?", dataProductId).
+		Order("bp.symbol").
+		Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func AddProductMapping(tx *gorm.DB, pm *ProductMapping) error {
+	return tx.Create(pm).Error
+}
+
+//=============================================================================
+
+func UpdateProductMapping(tx *gorm.DB, pm *ProductMapping) error {
+	return tx.Save(pm).Error
+}
+
+//=============================================================================
+
+func DeleteProductMapping(tx *gorm.DB, id uint) error {
+	return tx.Delete(&ProductMapping{}, id).Error
+}
+
+//=============================================================================
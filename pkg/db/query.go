@@ -0,0 +1,357 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+//===
+//=== List queries
+//===
+//=== Whitelisted fields can be filtered using URL params:
+//===
+//===   field=value          equality
+//===   field=v1,v2          any of the values (strings only)
+//===   field=from..to       inclusive range (ints and dates, both optional)
+//===
+//=== Dates use the YYYYMMDD format. Results are sorted using sort=f1:desc,f2
+//=== and q= searches for a text in the names of the entity.
+//===
+//=== Lists supporting cursors are paged by id using cursor= (empty for the
+//=== first page) instead of offset=, so that pages stay stable while rows
+//=== are inserted. The cursor of the next page is returned with each page
+//===
+//=============================================================================
+
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldBool
+	FieldDate
+)
+
+//=============================================================================
+
+type QueryField struct {
+	Column string
+	Type   FieldType
+	Where  string
+}
+
+//=============================================================================
+
+type QueryFields struct {
+	Alias  string
+	Fields map[string]QueryField
+	Search []string
+	Cursor bool
+}
+
+//=============================================================================
+
+type ListQuery struct {
+	Filter      map[string]any
+	alias       string
+	conditions  []condition
+	search      []string
+	text        string
+	sort        []string
+	cursor      bool
+	after       uint
+}
+
+//-----------------------------------------------------------------------------
+
+type condition struct {
+	query string
+	args  []any
+}
+
+//=============================================================================
+
+const (
+	ParamSort   = "sort"
+	ParamSearch = "q"
+	ParamCursor = "cursor"
+)
+
+//=============================================================================
+
+func NewListQuery(qf *QueryFields) *ListQuery {
+	return &ListQuery{
+		Filter: map[string]any{},
+		alias : qf.Alias,
+		search: qf.Search,
+	}
+}
+
+//=============================================================================
+
+func ParseListQuery(qf *QueryFields, params map[string][]string) (*ListQuery, error) {
+	q := NewListQuery(qf)
+
+	for name, values := range params {
+		field, ok := qf.Fields[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		err := q.addCondition(name, &field, values[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if values, ok := params[ParamSort]; ok && len(values) > 0 && values[0] != "" {
+		for _, item := range strings.Split(values[0], ",") {
+			name, dir, _ := strings.Cut(strings.TrimSpace(item), ":")
+
+			field, ok := qf.Fields[name]
+			if !ok || field.Column == "" {
+				return nil, req.NewBadRequestError("Invalid sort field: %v", name)
+			}
+
+			switch strings.ToLower(dir) {
+			case "", "asc":
+				q.sort = append(q.sort, field.Column +" ASC")
+			case "desc":
+				q.sort = append(q.sort, field.Column +" DESC")
+			default:
+				return nil, req.NewBadRequestError("Invalid sort direction: %v", item)
+			}
+		}
+	}
+
+	if values, ok := params[ParamSearch]; ok && len(values) > 0 {
+		q.text = strings.TrimSpace(values[0])
+	}
+
+	if values, ok := params[ParamCursor]; ok {
+		if !qf.Cursor {
+			return nil, req.NewBadRequestError("Cursor pagination is not supported: %v", ParamCursor)
+		}
+
+		if len(q.sort) > 0 {
+			return nil, req.NewBadRequestError("Sorting cannot be used with cursor pagination: %v", ParamSort)
+		}
+
+		q.cursor = true
+
+		if len(values) > 0 && values[0] != "" {
+			key, err := DecodeCursor(values[0])
+			if err != nil {
+				return nil, req.NewBadRequestError("Invalid cursor: %v", values[0])
+			}
+
+			q.after = uint(key)
+		}
+	}
+
+	return q, nil
+}
+
+//=============================================================================
+
+func (q *ListQuery) Where(tx *gorm.DB) *gorm.DB {
+	tx = tx.Where(qualifyFilter(q.Filter, q.alias))
+
+	for _, c := range q.conditions {
+		tx = tx.Where(c.query, c.args...)
+	}
+
+	if q.text != "" && len(q.search) > 0 {
+		var parts []string
+		var args  []any
+
+		for _, column := range q.search {
+			parts = append(parts, column +" LIKE ?")
+			args  = append(args, "%"+ q.text +"%")
+		}
+
+		tx = tx.Where(strings.Join(parts, " OR "), args...)
+	}
+
+	return tx
+}
+
+//=============================================================================
+
+func (q *ListQuery) Page(tx *gorm.DB, offset int, limit int) *gorm.DB {
+	if q.cursor {
+		if q.after > 0 {
+			tx = tx.Where(q.alias +".id > ?", q.after)
+		}
+
+		return tx.Order(q.alias +".id").Limit(limit)
+	}
+
+	for _, s := range q.sort {
+		tx = tx.Order(s)
+	}
+
+	return tx.Order(q.alias +".id").Offset(offset).Limit(limit)
+}
+
+//=============================================================================
+//--- A full page means that more rows could follow
+
+func (q *ListQuery) NextCursor(lastId uint, size int, limit int) string {
+	if !q.cursor || size < limit {
+		return ""
+	}
+
+	return EncodeCursor(int64(lastId))
+}
+
+//=============================================================================
+
+func EncodeCursor(key int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(key, 10)))
+}
+
+//=============================================================================
+
+func DecodeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func (q *ListQuery) addCondition(name string, field *QueryField, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	if field.Where != "" {
+		q.conditions = append(q.conditions, condition{ field.Where, []any{ splitValues(value) } })
+		return nil
+	}
+
+	switch field.Type {
+	case FieldString:
+		values := splitValues(value)
+		if len(values) == 1 {
+			q.conditions = append(q.conditions, condition{ field.Column +" = ?", []any{ values[0] } })
+		} else {
+			q.conditions = append(q.conditions, condition{ field.Column +" IN ?", []any{ values } })
+		}
+
+	case FieldBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return req.NewBadRequestError("Invalid filter value: %v", name +"="+ value)
+		}
+		q.conditions = append(q.conditions, condition{ field.Column +" = ?", []any{ b } })
+
+	case FieldInt, FieldDate:
+		from, to, isRange := strings.Cut(value, "..")
+		if !isRange {
+			to = from
+		}
+
+		if from != "" {
+			v, err := parseBound(field.Type, from, false)
+			if err != nil {
+				return req.NewBadRequestError("Invalid filter value: %v", name +"="+ value)
+			}
+			q.conditions = append(q.conditions, condition{ field.Column +" >= ?", []any{ v } })
+		}
+
+		if to != "" {
+			v, err := parseBound(field.Type, to, true)
+			if err != nil {
+				return req.NewBadRequestError("Invalid filter value: %v", name +"="+ value)
+			}
+			q.conditions = append(q.conditions, condition{ field.Column +" <= ?", []any{ v } })
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func parseBound(fieldType FieldType, value string, endDay bool) (any, error) {
+	if fieldType == FieldInt {
+		return strconv.Atoi(value)
+	}
+
+	d, err := datatype.ParseIntDate(value, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ToDateTime(endDay, time.UTC), nil
+}
+
+//=============================================================================
+
+func splitValues(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+//=============================================================================
+//--- Joined queries need the table alias to avoid ambiguous columns
+
+func qualifyFilter(filter map[string]any, alias string) map[string]any {
+	res := map[string]any{}
+
+	for key, value := range filter {
+		res[alias +"."+ key] = value
+	}
+
+	return res
+}
+
+//=============================================================================
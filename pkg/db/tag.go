@@ -0,0 +1,186 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package db
+
+import (
+	"github.com/tradalia/core/req"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func GetTags(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]TagFull, error) {
+	var list []TagFull
+	res := tx.Table("tag").
+		Select("tag.*, COUNT(trading_system_tag.trading_system_id) as count").
+		Joins("LEFT JOIN trading_system_tag on trading_system_tag.tag_id = tag.id").
+		Where(filter).
+		Group("tag.id").
+		Order("tag.name").
+		Offset(offset).Limit(limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountTags(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&Tag{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
+func GetTagById(tx *gorm.DB, id uint) (*Tag, error) {
+	var list []Tag
+	res := tx.Find(&list, id)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func GetTagByName(tx *gorm.DB, username string, name string) (*Tag, error) {
+	var list []Tag
+	res := tx.Find(&list, "username = ? and name = ?", username, name)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddTag(tx *gorm.DB, t *Tag) error {
+	return tx.Create(t).Error
+}
+
+//=============================================================================
+
+func UpdateTag(tx *gorm.DB, t *Tag) error {
+	return tx.Save(t).Error
+}
+
+//=============================================================================
+
+func DeleteTag(tx *gorm.DB, id uint) error {
+	return tx.Delete(&Tag{}, id).Error
+}
+
+//=============================================================================
+//===
+//=== Trading system links
+//===
+//=============================================================================
+
+func GetTagsByTradingSystemId(tx *gorm.DB, id uint) (*[]Tag, error) {
+	var list []Tag
+	res := tx.
+		Joins("JOIN trading_system_tag on trading_system_tag.tag_id = tag.id").
+		Where("trading_system_tag.trading_system_id = ?", id).
+		Order("tag.name").
+		Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemIdsByTagId(tx *gorm.DB, id uint) ([]uint, error) {
+	var list []uint
+	res := tx.Model(&TradingSystemTag{}).Where("tag_id = ?", id).Pluck("trading_system_id", &list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return list, nil
+}
+
+//=============================================================================
+
+func SetTradingSystemTags(tx *gorm.DB, id uint, tagIds []uint) error {
+	err := DeleteTradingSystemTags(tx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, tagId := range tagIds {
+		err = AddTradingSystemTag(tx, id, tagId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//=============================================================================
+
+func AddTradingSystemTag(tx *gorm.DB, id uint, tagId uint) error {
+	return tx.Create(&TradingSystemTag{ TradingSystemId: id, TagId: tagId }).Error
+}
+
+//=============================================================================
+
+func DeleteTradingSystemTags(tx *gorm.DB, id uint) error {
+	return tx.Delete(&TradingSystemTag{}, "trading_system_id = ?", id).Error
+}
+
+//=============================================================================
+
+func DeleteTagLinks(tx *gorm.DB, tagId uint) error {
+	return tx.Delete(&TradingSystemTag{}, "tag_id = ?", tagId).Error
+}
+
+//=============================================================================
@@ -44,6 +44,19 @@ func GetTradingSessions(tx *gorm.DB, filter map[string]any, offset int, limit in
 
 //=============================================================================
 
+func CountTradingSessions(tx *gorm.DB, filter map[string]any) (int64, error) {
+	var total int64
+	res := tx.Model(&TradingSession{}).Where(filter).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetTradingSessionById(tx *gorm.DB, id uint) (*TradingSession, error) {
 	var list []TradingSession
 	res := tx.Find(&list, id)
@@ -60,3 +73,26 @@ func GetTradingSessionById(tx *gorm.DB, id uint) (*TradingSession, error) {
 }
 
 //=============================================================================
+
+func GetTradingSessionByName(tx *gorm.DB, username string, name string) (*TradingSession, error) {
+	var list []TradingSession
+	res := tx.Find(&list, "username = ? and name = ?", username, name)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddTradingSession(tx *gorm.DB, ts *TradingSession) error {
+	return tx.Create(ts).Error
+}
+
+//=============================================================================
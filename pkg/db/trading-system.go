@@ -25,15 +25,40 @@ THE SOFTWARE.
 package db
 
 import (
+	"time"
+
 	"github.com/tradalia/core/req"
 	"gorm.io/gorm"
 )
 
 //=============================================================================
 
-func GetTradingSystems(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]TradingSystemFull, error) {
+var TradingSystemQueryFields = QueryFields{
+	Alias : "ts",
+	Search: []string{ "ts.name", "ts.external_ref" },
+	Cursor: true,
+	Fields: map[string]QueryField{
+		"name"         : { Column: "ts.name",          Type: FieldString },
+		"strategyType" : { Column: "ts.strategy_type", Type: FieldString },
+		"status"       : { Column: "ts.status",        Type: FieldString },
+		"timeframe"    : { Column: "ts.timeframe",     Type: FieldInt    },
+		"overnight"    : { Column: "ts.overnight",     Type: FieldBool   },
+		"finalized"    : { Column: "ts.finalized",     Type: FieldBool   },
+		"createdAt"    : { Column: "ts.created_at",    Type: FieldDate   },
+		"updatedAt"    : { Column: "ts.updated_at",    Type: FieldDate   },
+		"symbol"       : { Column: "(SELECT x.symbol FROM data_product x WHERE x.id = ts.data_product_id)", Type: FieldString },
+		"exchangeCode" : { Column: "(SELECT xe.code FROM broker_product x JOIN exchange xe on xe.id = x.exchange_id WHERE x.id = ts.broker_product_id)", Type: FieldString },
+		"tags"         : { Where : "ts.id IN (SELECT xt.trading_system_id FROM trading_system_tag xt JOIN tag t on t.id = xt.tag_id WHERE t.name IN ?)" },
+	},
+}
+
+//=============================================================================
+
+func GetTradingSystems(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]TradingSystemFull, error) {
 	var list []TradingSystemFull
-	res := tx.Where(filter).Offset(offset).Limit(limit).Find(&list)
+	query := tx.Table("trading_system ts")
+
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -44,6 +69,38 @@ func GetTradingSystems(tx *gorm.DB, filter map[string]any, offset int, limit int
 
 //=============================================================================
 
+func GetTradingSystemsFull(tx *gorm.DB, q *ListQuery, offset int, limit int) (*[]TradingSystemFull, error) {
+	var list []TradingSystemFull
+	query := tx.Table("trading_system ts").
+		Select("ts.*, dp.symbol as data_symbol, bp.symbol as broker_symbol, s.name as trading_session").
+		Joins("LEFT JOIN data_product    dp on ts.data_product_id   = dp.id").
+		Joins("LEFT JOIN broker_product  bp on ts.broker_product_id = bp.id").
+		Joins("LEFT JOIN trading_session s  on ts.trading_session_id= s.id")
+
+	res := q.Page(q.Where(query), offset, limit).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func CountTradingSystems(tx *gorm.DB, q *ListQuery) (int64, error) {
+	var total int64
+	res := q.Where(tx.Table("trading_system ts")).Count(&total)
+
+	if res.Error != nil {
+		return 0, req.NewServerErrorByError(res.Error)
+	}
+
+	return total, nil
+}
+
+//=============================================================================
+
 func GetTradingSystemById(tx *gorm.DB, id uint) (*TradingSystem, error) {
 	var list []TradingSystem
 	res := tx.Find(&list, id)
@@ -61,16 +118,43 @@ func GetTradingSystemById(tx *gorm.DB, id uint) (*TradingSystem, error) {
 
 //=============================================================================
 
-func GetTradingSystemsFull(tx *gorm.DB, filter map[string]any, offset int, limit int) (*[]TradingSystemFull, error) {
-	var list []TradingSystemFull
+func GetTradingSystemsWithLegacyTags(tx *gorm.DB) (*[]TradingSystem, error) {
+	var list []TradingSystem
+	res := tx.Order("id").Find(&list, "tags IS NOT NULL AND tags <> ''")
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemsByExtRef(tx *gorm.DB, username string, externalRef string) (*[]TradingSystem, error) {
+	var list []TradingSystem
+	res := tx.Order("id").Find(&list, "external_ref = ? and username = ?", externalRef, username)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetExternalRefDuplicates(tx *gorm.DB) (*[]ExternalRefDuplicate, error) {
+	var list []ExternalRefDuplicate
 	query :=
-		"SELECT ts.*, dp.symbol as data_symbol, bp.symbol as broker_symbol, s.name as trading_session " +
-		"FROM trading_system ts " +
-		"LEFT JOIN data_product    dp on ts.data_product_id   = dp.id " +
-		"LEFT JOIN broker_product  bp on ts.broker_product_id = bp.id " +
-		"LEFT JOIN trading_session s  on ts.trading_session_id= s.id"
+		"SELECT username, external_ref, COUNT(*) as count, GROUP_CONCAT(id ORDER BY id) as ids " +
+		"FROM trading_system " +
+		"WHERE external_ref <> '' " +
+		"GROUP BY username, external_ref " +
+		"HAVING COUNT(*) > 1 " +
+		"ORDER BY username, external_ref"
 
-	res := tx.Raw(query).Where(filter).Offset(offset).Limit(limit).Find(&list)
+	res := tx.Raw(query).Find(&list)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
@@ -81,19 +165,47 @@ func GetTradingSystemsFull(tx *gorm.DB, filter map[string]any, offset int, limit
 
 //=============================================================================
 
-func GetTradingSystemByExtRef(tx *gorm.DB, username string, externalRef string) (*TradingSystem, error) {
+func GetTradingSystemsByName(tx *gorm.DB, username string, name string) (*[]TradingSystem, error) {
 	var list []TradingSystem
-	res := tx.Find(&list, "external_ref = ? and username = ?", externalRef, username)
+	res := tx.Find(&list, "username = ? and name = ?", username, name)
 
 	if res.Error != nil {
 		return nil, req.NewServerErrorByError(res.Error)
 	}
 
-	if len(list) == 1 {
-		return &list[0], nil
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemsByProducts(tx *gorm.DB, dataProductId uint, brokerProductId uint) (*[]TradingSystem, error) {
+	var list []TradingSystem
+	res := tx.Find(&list, "data_product_id = ? and broker_product_id = ?", dataProductId, brokerProductId)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
 	}
 
-	return nil, nil
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemsByExchangeId(tx *gorm.DB, id uint) (*[]TradingSystem, error) {
+	var list []TradingSystem
+	query :=
+		"SELECT ts.* " +
+		"FROM trading_system ts " +
+		"JOIN broker_product bp on ts.broker_product_id = bp.id " +
+		"WHERE bp.exchange_id = ?"
+
+	res := tx.Raw(query, id).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
 }
 
 //=============================================================================
@@ -115,3 +227,66 @@ func DeleteTradingSystem(tx *gorm.DB, id uint) error {
 }
 
 //=============================================================================
+//===
+//=== Transitions
+//===
+//=============================================================================
+
+func GetTradingSystemTransitions(tx *gorm.DB, tsId uint) (*[]TradingSystemTransition, error) {
+	var list []TradingSystemTransition
+	res := tx.Where("trading_system_id = ?", tsId).Order("created_at").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func AddTradingSystemTransition(tx *gorm.DB, tst *TradingSystemTransition) error {
+	return tx.Create(tst).Error
+}
+
+//=============================================================================
+//===
+//=== Versions
+//===
+//=============================================================================
+
+func GetTradingSystemVersions(tx *gorm.DB, tsId uint) (*[]TradingSystemVersion, error) {
+	var list []TradingSystemVersion
+	res := tx.Where("trading_system_id = ?", tsId).Order("version").Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	return &list, nil
+}
+
+//=============================================================================
+
+func GetTradingSystemVersionAt(tx *gorm.DB, tsId uint, at time.Time) (*TradingSystemVersion, error) {
+	var list []TradingSystemVersion
+	res := tx.Where("trading_system_id = ? AND created_at <= ?", tsId, at).Order("version desc").Limit(1).Find(&list)
+
+	if res.Error != nil {
+		return nil, req.NewServerErrorByError(res.Error)
+	}
+
+	if len(list) == 1 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+//=============================================================================
+
+func AddTradingSystemVersion(tx *gorm.DB, tsv *TradingSystemVersion) error {
+	return tx.Create(tsv).Error
+}
+
+//=============================================================================
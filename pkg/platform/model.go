@@ -36,12 +36,54 @@ type SystemList struct {
 //=============================================================================
 
 type System struct {
-	Code                  string `json:"code"`
-	Name                  string `json:"name"`
-	SupportsData          bool   `json:"supportsData"`
-	SupportsBroker        bool   `json:"supportsBroker"`
-	SupportsMultipleData  bool   `json:"supportsMultipleData"`
-	SupportsInventory     bool   `json:"supportsInventory"`
+	Code                  string        `json:"code"`
+	Name                  string        `json:"name"`
+	SupportsData          bool          `json:"supportsData"`
+	SupportsBroker        bool          `json:"supportsBroker"`
+	SupportsMultipleData  bool          `json:"supportsMultipleData"`
+	SupportsInventory     bool          `json:"supportsInventory"`
+	ConfigParams          []ConfigParam `json:"configParams"`
+}
+
+//=============================================================================
+
+const (
+	ConfigParamString = "string"
+	ConfigParamInt    = "int"
+	ConfigParamFloat  = "float"
+	ConfigParamBool   = "bool"
+)
+
+//-----------------------------------------------------------------------------
+
+type ConfigParam struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Secret   bool     `json:"secret"`
+	Options  []string `json:"options,omitempty"`
+}
+
+//=============================================================================
+
+type InstrumentList struct {
+	Offset   int          `json:"offset"`
+	Limit    int          `json:"limit"`
+	Overflow bool         `json:"overflow"`
+	Result   []Instrument `json:"result"`
+}
+
+//=============================================================================
+
+type Instrument struct {
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name"`
+	ExchangeCode string  `json:"exchangeCode"`
+	PointValue   float32 `json:"pointValue"`
+	Increment    float64 `json:"increment"`
+	MarketType   string  `json:"marketType"`
+	ProductType  string  `json:"productType"`
+	Months       string  `json:"months"`
 }
 
 //=============================================================================
@@ -25,6 +25,9 @@ THE SOFTWARE.
 package platform
 
 import (
+	"errors"
+	"net/url"
+	"strconv"
 	"github.com/tradalia/core/auth"
 	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/app"
@@ -95,6 +98,54 @@ func GetSystems(c *auth.Context) (*[]System, error) {
 	return systems.l, nil
 }
 
+//=============================================================================
+
+func GetInstruments(c *auth.Context, username string, code string) (*[]Instrument, error) {
+	c.Log.Info("GetInstruments: Getting instruments from system adapter", "connection", code)
+
+	list, err := FetchInstruments(c.Config.(*app.Config).Platform.System, c.Token, username, code)
+	if err != nil {
+		c.Log.Error("GetInstruments: Got an error from system adapter", "error", err.Error())
+		return nil, req.NewServerError("Cannot communicate with system-adapter: %v", err.Error())
+	}
+
+	c.Log.Info("GetInstruments: Returning instruments", "instruments", len(*list))
+	return list, nil
+}
+
+//=============================================================================
+//--- Used by background processes, which have no user context. The adapter
+//--- returns the instruments in pages, which are all read
+
+func FetchInstruments(systemUrl string, token string, username string, code string) (*[]Instrument, error) {
+	list   := []Instrument{}
+	client := req.GetClient("bf")
+	offset := 0
+
+	for {
+		var instrumentList InstrumentList
+
+		address := systemUrl +"/v1/connections/"+ url.PathEscape(code) +"/instruments?offset="+ strconv.Itoa(offset) +"&limit="+ strconv.Itoa(req.MaxQueryLimit)
+		err     := req.DoGetOnBehalfOf(client, address, &instrumentList, token, username)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if instrumentList.Offset != offset {
+			return nil, errors.New("System adapter ignored the requested offset: "+ strconv.Itoa(offset))
+		}
+
+		list = append(list, instrumentList.Result...)
+
+		if !instrumentList.Overflow || len(instrumentList.Result) == 0 {
+			return &list, nil
+		}
+
+		offset += len(instrumentList.Result)
+	}
+}
+
 //=============================================================================
 //===
 //=== Private methods
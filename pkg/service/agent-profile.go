@@ -26,7 +26,9 @@ package service
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/core/process/agentscanner"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
@@ -39,13 +41,13 @@ func getAgentProfiles(c *auth.Context) {
 
 	if err == nil {
 		err = db.RunInTransaction(func(tx *gorm.DB) error {
-			list, err := business.GetAgentProfiles(tx, c, filter, offset, limit)
+			list, total, err := business.GetAgentProfiles(tx, c, filter, offset, limit)
 
 			if err != nil {
 				return err
 			}
 
-			return c.ReturnList(list, offset, limit, len(*list))
+			return returnPage(c, list, offset, limit, len(*list), total, "")
 		})
 	}
 
@@ -53,3 +55,27 @@ func getAgentProfiles(c *auth.Context) {
 }
 
 //=============================================================================
+
+func getAgentProfileScanResult(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			_, err := business.GetAgentProfileById(tx, c, id)
+			return err
+		})
+
+		if err == nil {
+			sr := agentscanner.GetScanResult(id)
+			if sr == nil {
+				err = req.NewNotFoundError("Agent profile has not been scanned yet: %v", id)
+			} else {
+				err = c.ReturnObject(sr)
+			}
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -25,7 +25,11 @@ THE SOFTWARE.
 package service
 
 import (
+	"strconv"
+
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
@@ -34,22 +38,27 @@ import (
 //=============================================================================
 
 func getBrokerProducts(c *auth.Context) {
-	filter := map[string]any{}
 	offset, limit, err := c.GetPagingParams()
 
 	if err == nil {
-		details, err := c.GetParamAsBool("details", false)
+		var q *db.ListQuery
+		q, err = getListQuery(c, &db.BrokerProductQueryFields)
 
 		if err == nil {
-			err = db.RunInTransaction(func(tx *gorm.DB) error {
-				list, err := business.GetBrokerProducts(tx, c, filter, offset, limit, details)
+			var details bool
+			details, err = c.GetParamAsBool("details", false)
 
-				if err != nil {
-					return err
-				}
+			if err == nil {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					list, total, err := business.GetBrokerProducts(tx, c, q, offset, limit, details)
 
-				return c.ReturnList(list, offset, limit, len(*list))
-			})
+					if err != nil {
+						return err
+					}
+
+					return returnPage(c, list, offset, limit, len(*list), total, "")
+				})
+			}
 		}
 	}
 
@@ -123,3 +132,161 @@ func updateBrokerProduct(c *auth.Context) {
 }
 
 //=============================================================================
+
+func importBrokerProducts(c *auth.Context) {
+	data, err := c.Gin.GetRawData()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			res, err := business.ImportBrokerProducts(tx, c, data)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(res)
+		})
+	} else {
+		err = req.NewBadRequestError("Cannot read CSV file: %v", err.Error())
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+//--- Without a date, the whole history is returned
+
+func getBrokerProductMargins(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		var date datatype.IntDate
+		date, err = datatype.ParseIntDate(c.GetParamAsString("date", ""), false)
+
+		if err != nil {
+			err = req.NewBadRequestError("Invalid 'date' param: %v", err.Error())
+		} else {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				if date.IsNil() {
+					list, err := business.GetBrokerProductCosts(tx, c, id)
+
+					if err != nil {
+						return err
+					}
+
+					return c.ReturnList(list, 0, len(*list), len(*list))
+				}
+
+				bpc, err := business.GetBrokerProductCostAt(tx, c, id, date)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(bpc)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getCommissionModel(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cme, err := business.GetCommissionModel(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cme)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func setCommissionModel(c *auth.Context) {
+	var cms business.CommissionModelSpec
+	err := c.BindParamsFromBody(&cms)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				cme, err := business.SetCommissionModel(tx, c, id, &cms)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(cme)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func deleteCommissionModel(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cm, err := business.DeleteCommissionModel(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cm)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func calculateCommission(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		var contracts int
+		contracts, err = c.GetParamAsInt("contracts", 0)
+
+		if err == nil {
+			var price float64
+			price, err = strconv.ParseFloat(c.GetParamAsString("price", "0"), 64)
+
+			if err != nil {
+				err = req.NewBadRequestError("Invalid 'price' param: %v", err.Error())
+			} else {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					quote, err := business.CalculateCommission(tx, c, id, contracts, price)
+
+					if err != nil {
+						return err
+					}
+
+					return c.ReturnObject(quote)
+				})
+			}
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -0,0 +1,203 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package service
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func getCatalogProducts(c *auth.Context) {
+	filter := map[string]any{}
+	offset, limit, err := c.GetPagingParams()
+
+	if err == nil {
+		var exchangeId int
+		exchangeId, err = c.GetParamAsInt("exchangeId", 0)
+
+		if err == nil {
+			if exchangeId != 0 {
+				filter["exchange_id"] = exchangeId
+			}
+
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				list, total, err := business.GetCatalogProducts(tx, filter, offset, limit)
+
+				if err != nil {
+					return err
+				}
+
+				return returnPage(c, list, offset, limit, len(*list), total, "")
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getCatalogProductById(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cp, err := business.GetCatalogProductById(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cp)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addCatalogProduct(c *auth.Context) {
+	var cps business.CatalogProductSpec
+	err := c.BindParamsFromBody(&cps)
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cp, err := business.AddCatalogProduct(tx, c, &cps)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cp)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func updateCatalogProduct(c *auth.Context) {
+	var cps business.CatalogProductSpec
+	err := c.BindParamsFromBody(&cps)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				cp, err := business.UpdateCatalogProduct(tx, c, id, &cps)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(cp)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func deleteCatalogProduct(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cp, err := business.DeleteCatalogProduct(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cp)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addDataProductFromCatalog(c *auth.Context) {
+	var cdps business.CatalogDataProductSpec
+	err := c.BindParamsFromBody(&cdps)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				dp, err := business.AddDataProductFromCatalog(tx, c, id, &cdps)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(dp)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addBrokerProductFromCatalog(c *auth.Context) {
+	var cbps business.CatalogBrokerProductSpec
+	err := c.BindParamsFromBody(&cbps)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				bp, err := business.AddBrokerProductFromCatalog(tx, c, id, &cbps)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(bp)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -25,8 +25,13 @@ THE SOFTWARE.
 package service
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/core/process/instrumentsync"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
 )
@@ -34,19 +39,23 @@ import (
 //=============================================================================
 
 func getConnections(c *auth.Context) {
-	filter := map[string]any{}
 	offset, limit, err := c.GetPagingParams()
 
 	if err == nil {
-		err = db.RunInTransaction(func(tx *gorm.DB) error {
-			list, err := business.GetConnections(tx, c, filter, offset, limit)
+		var q *db.ListQuery
+		q, err = getListQuery(c, &db.ConnectionQueryFields)
 
-			if err != nil {
-				return err
-			}
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				list, total, err := business.GetConnections(tx, c, q, offset, limit)
 
-			return c.ReturnList(list, offset, limit, len(*list))
-		})
+				if err != nil {
+					return err
+				}
+
+				return returnPage(c, list, offset, limit, len(*list), total, "")
+			})
+		}
 	}
 
 	c.ReturnError(err)
@@ -90,7 +99,7 @@ func addConnection(c *auth.Context) {
 		})
 	}
 
-	c.ReturnError(err)
+	returnConnectionError(c, err)
 }
 
 //=============================================================================
@@ -116,7 +125,7 @@ func updateConnection(c *auth.Context) {
 		}
 	}
 
-	c.ReturnError(err)
+	returnConnectionError(c, err)
 }
 
 //=============================================================================
@@ -140,3 +149,268 @@ func deleteConnection(c *auth.Context) {
 }
 
 //=============================================================================
+
+func getConnectionInstruments(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, err := business.GetRemoteInstruments(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnList(list, 0, len(*list), len(*list))
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addDataProductsFromInstruments(c *auth.Context) {
+	var iss business.InstrumentSelectionSpec
+	err := c.BindParamsFromBody(&iss)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				res, err := business.AddDataProductsFromInstruments(tx, c, id, &iss)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(res)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addBrokerProductsFromInstruments(c *auth.Context) {
+	var iss business.InstrumentSelectionSpec
+	err := c.BindParamsFromBody(&iss)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				res, err := business.AddBrokerProductsFromInstruments(tx, c, id, &iss)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(res)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+//--- Returns the last periodic check, unless a refresh is requested
+
+func getInstrumentDrift(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		var refresh bool
+		refresh, err = c.GetParamAsBool("refresh", false)
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				if refresh {
+					report, err := business.CheckInstrumentDrift(tx, c, id)
+
+					if err != nil {
+						return err
+					}
+
+					instrumentsync.SetDriftReport(report)
+					return c.ReturnObject(report)
+				}
+
+				_, err := business.GetConnectionById(tx, c, id)
+				if err != nil {
+					return err
+				}
+
+				report := instrumentsync.GetDriftReport(id)
+				if report == nil {
+					return req.NewNotFoundError("Connection has not been reconciled yet: %v", id)
+				}
+
+				return c.ReturnObject(report)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getConnectionConfig(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			conn, err := business.GetConnectionConfig(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(conn)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func reencryptConnections(c *auth.Context) {
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		res, err := business.ReencryptConnections(tx, c)
+
+		if err != nil {
+			return err
+		}
+
+		return c.ReturnObject(res)
+	})
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getConnectionStatusHistory(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		var days int
+		days, err = c.GetParamAsInt("days", business.DefaultStatusHistoryDays)
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				csh, err := business.GetConnectionStatusHistory(tx, c, id, days)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(csh)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getConnectionUptime(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cu, err := business.GetConnectionUptime(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cu)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func connectConnection(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			conn, err := business.ConnectConnection(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(conn)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func disconnectConnection(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			conn, err := business.DisconnectConnection(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(conn)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+type configParamsErrorResponse struct {
+	Code   int                         `json:"code"`
+	Error  string                      `json:"error"`
+	Params []business.ConfigParamError `json:"params"`
+}
+
+//=============================================================================
+//--- Invalid system config params are returned with an error for each param
+
+func returnConnectionError(c *auth.Context, err error) {
+	var cpe business.ConfigParamsError
+
+	if errors.As(err, &cpe) {
+		c.Log.Error("returnConnectionError: Invalid system config params", "error", cpe.Error())
+		c.Gin.JSON(http.StatusBadRequest, &configParamsErrorResponse{
+			Code  : http.StatusBadRequest,
+			Error : cpe.Error(),
+			Params: cpe.Errors,
+		})
+		return
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -26,6 +26,8 @@ package service
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
@@ -48,3 +50,141 @@ func getCurrencies(c *auth.Context) {
 }
 
 //=============================================================================
+//--- History uses cursor pagination only: the cursor is the date of the last
+//--- value returned
+
+func getCurrencyHistory(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		var limit int
+		_, limit, err = c.GetPagingParams()
+
+		if err == nil {
+			var from, to, before datatype.IntDate
+			from, to, before, err = getCurrencyHistoryParams(c)
+
+			if err == nil {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					list, total, err := business.GetCurrencyHistory(tx, c, id, from, to, before, limit)
+
+					if err != nil {
+						return err
+					}
+
+					nextCursor := ""
+					if size := len(*list); size == limit {
+						nextCursor = db.EncodeCursor(int64((*list)[size -1].Date))
+					}
+
+					return returnPage(c, list, 0, limit, len(*list), total, nextCursor)
+				})
+			}
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getCurrencyReviews(c *auth.Context) {
+	filter := map[string]any{}
+	offset, limit, err := c.GetPagingParams()
+
+	if err == nil {
+		status := c.GetParamAsString("status", db.CurrencyReviewPending)
+		filter["status"] = status
+
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, total, err := business.GetCurrencyReviews(tx, c, filter, offset, limit)
+
+			if err != nil {
+				return err
+			}
+
+			return returnPage(c, list, offset, limit, len(*list), total, "")
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func acceptCurrencyReview(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			cr, err := business.AcceptCurrencyReview(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(cr)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func overrideCurrencyReview(c *auth.Context) {
+	var cros business.CurrencyReviewOverrideSpec
+	err := c.BindParamsFromBody(&cros)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				cr, err := business.OverrideCurrencyReview(tx, c, id, &cros)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(cr)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getCurrencyHistoryParams(c *auth.Context) (datatype.IntDate, datatype.IntDate, datatype.IntDate, error) {
+	from, err := datatype.ParseIntDate(c.GetParamAsString("from", ""), false)
+	if err != nil {
+		return 0, 0, 0, req.NewBadRequestError("Invalid 'from' param: %v", err.Error())
+	}
+
+	to, err := datatype.ParseIntDate(c.GetParamAsString("to", ""), false)
+	if err != nil {
+		return 0, 0, 0, req.NewBadRequestError("Invalid 'to' param: %v", err.Error())
+	}
+
+	var before datatype.IntDate
+
+	if cursor := c.GetParamAsString(db.ParamCursor, ""); cursor != "" {
+		key, err := db.DecodeCursor(cursor)
+		if err != nil {
+			return 0, 0, 0, req.NewBadRequestError("Invalid cursor: %v", cursor)
+		}
+
+		before = datatype.IntDate(key)
+	}
+
+	return from, to, before, nil
+}
+
+//=============================================================================
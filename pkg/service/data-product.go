@@ -26,6 +26,7 @@ package service
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
@@ -34,22 +35,27 @@ import (
 //=============================================================================
 
 func getDataProducts(c *auth.Context) {
-	filter := map[string]any{}
 	offset, limit, err := c.GetPagingParams()
 
 	if err == nil {
-		details, err := c.GetParamAsBool("details", false)
+		var q *db.ListQuery
+		q, err = getListQuery(c, &db.DataProductQueryFields)
 
 		if err == nil {
-			err = db.RunInTransaction(func(tx *gorm.DB) error {
-				list, err := business.GetDataProducts(tx, c, filter, offset, limit, details)
+			var details bool
+			details, err = c.GetParamAsBool("details", false)
 
-				if err != nil {
-					return err
-				}
+			if err == nil {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					list, total, err := business.GetDataProducts(tx, c, q, offset, limit, details)
 
-				return c.ReturnList(list, offset, limit, len(*list))
-			})
+					if err != nil {
+						return err
+					}
+
+					return returnPage(c, list, offset, limit, len(*list), total, "")
+				})
+			}
 		}
 	}
 
@@ -123,3 +129,45 @@ func updateDataProduct(c *auth.Context) {
 }
 
 //=============================================================================
+
+func importDataProducts(c *auth.Context) {
+	data, err := c.Gin.GetRawData()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			res, err := business.ImportDataProducts(tx, c, data)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(res)
+		})
+	} else {
+		err = req.NewBadRequestError("Cannot read CSV file: %v", err.Error())
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getMappedBrokerProducts(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, err := business.GetMappedBrokerProducts(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnList(list, 0, len(*list), len(*list))
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -26,6 +26,8 @@ package service
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
@@ -48,3 +50,176 @@ func getExchanges(c *auth.Context) {
 }
 
 //=============================================================================
+
+func getExchangeById(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			ex, err := business.GetExchangeById(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(ex)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addExchange(c *auth.Context) {
+	var es business.ExchangeSpec
+	err := c.BindParamsFromBody(&es)
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			ex, err := business.AddExchange(tx, c, &es)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(ex)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func updateExchange(c *auth.Context) {
+	var es business.ExchangeSpec
+	err := c.BindParamsFromBody(&es)
+
+	if err == nil {
+		var id uint
+		id,err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				ex, err := business.UpdateExchange(tx, c, id, &es)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(ex)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func deleteExchange(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			ex,err := business.DeleteExchange(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(ex)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getExchangeCalendar(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		var from, to datatype.IntDate
+		from, err = datatype.ParseIntDate(c.GetParamAsString("from", ""), true)
+
+		if err == nil {
+			to, err = datatype.ParseIntDate(c.GetParamAsString("to", ""), true)
+		}
+
+		if err != nil {
+			err = req.NewBadRequestError("Invalid 'from' or 'to' param: %v", err.Error())
+		} else {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				cal, err := business.GetExchangeCalendar(tx, c, id, from, to)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(cal)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func setExchangeHours(c *auth.Context) {
+	var specs []business.ExchangeHoursSpec
+	err := c.BindParamsFromBody(&specs)
+
+	if err == nil {
+		var id uint
+		id,err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				list, err := business.SetExchangeHours(tx, c, id, &specs)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnList(list, 0, len(*list), len(*list))
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func importExchangeHolidays(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		format := c.GetParamAsString("format", business.CalendarFormatCsv)
+
+		var data []byte
+		data, err = c.Gin.GetRawData()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				res, err := business.ImportExchangeHolidays(tx, c, id, format, data)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(res)
+			})
+		} else {
+			err = req.NewBadRequestError("Cannot read calendar file: %v", err.Error())
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -0,0 +1,105 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package service
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func exportInventory(c *auth.Context) {
+	format   := c.GetParamAsString("format",   business.InventoryFormatJson)
+	username := c.GetParamAsString("username", "")
+
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		doc, err := business.ExportInventory(tx, c, username)
+		if err != nil {
+			return err
+		}
+
+		data, err := business.EncodeInventory(doc, format)
+		if err != nil {
+			return err
+		}
+
+		return c.ReturnData(getInventoryContentType(format), data)
+	})
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func importInventory(c *auth.Context) {
+	format := c.GetParamAsString("format", business.InventoryFormatJson)
+	dryRun, err := c.GetParamAsBool("dryRun", false)
+
+	if err == nil {
+		var data []byte
+		data, err = c.Gin.GetRawData()
+
+		if err == nil {
+			var doc *business.InventoryDocument
+			doc, err = business.DecodeInventory(data, format)
+
+			if err == nil {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					res, err := business.ImportInventory(tx, c, doc, dryRun)
+
+					if err != nil {
+						return err
+					}
+
+					return c.ReturnObject(res)
+				})
+			}
+		} else {
+			err = req.NewBadRequestError("Cannot read inventory file: %v", err.Error())
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+//===
+//=== Private functions
+//===
+//=============================================================================
+
+func getInventoryContentType(format string) string {
+	if format == business.InventoryFormatYaml {
+		return "application/yaml"
+	}
+
+	return "application/json"
+}
+
+//=============================================================================
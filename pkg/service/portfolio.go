@@ -0,0 +1,142 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package service
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func getPortfolios(c *auth.Context) {
+	filter := map[string]any{}
+	offset, limit, err := c.GetPagingParams()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, total, err := business.GetPortfolios(tx, c, filter, offset, limit)
+
+			if err != nil {
+				return err
+			}
+
+			return returnPage(c, list, offset, limit, len(*list), total, "")
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getPortfolioById(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			p, err := business.GetPortfolioById(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(p)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addPortfolio(c *auth.Context) {
+	var ps business.PortfolioSpec
+	err := c.BindParamsFromBody(&ps)
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			p, err := business.AddPortfolio(tx, c, &ps)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(p)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func updatePortfolio(c *auth.Context) {
+	var ps business.PortfolioSpec
+	err := c.BindParamsFromBody(&ps)
+
+	if err == nil {
+		var id uint
+		id,err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				p, err := business.UpdatePortfolio(tx, c, id, &ps)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(p)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func deletePortfolio(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			p, err := business.DeletePortfolio(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(p)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
@@ -0,0 +1,159 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package service
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/inventory-server/pkg/business"
+	"github.com/tradalia/inventory-server/pkg/db"
+	"gorm.io/gorm"
+)
+
+//=============================================================================
+
+func getProductMappings(c *auth.Context) {
+	filter := map[string]any{}
+	offset, limit, err := c.GetPagingParams()
+
+	if err == nil {
+		var dataProductId, brokerProductId int
+		dataProductId, err = c.GetParamAsInt("dataProductId", 0)
+
+		if err == nil {
+			brokerProductId, err = c.GetParamAsInt("brokerProductId", 0)
+
+			if err == nil {
+				if dataProductId != 0 {
+					filter["data_product_id"] = dataProductId
+				}
+
+				if brokerProductId != 0 {
+					filter["broker_product_id"] = brokerProductId
+				}
+
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					list, total, err := business.GetProductMappings(tx, c, filter, offset, limit)
+
+					if err != nil {
+						return err
+					}
+
+					return returnPage(c, list, offset, limit, len(*list), total, "")
+				})
+			}
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getProductMappingById(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			pm, err := business.GetProductMappingById(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(pm)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func addProductMapping(c *auth.Context) {
+	var pms business.ProductMappingSpec
+	err := c.BindParamsFromBody(&pms)
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			pm, err := business.AddProductMapping(tx, c, &pms)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(pm)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func updateProductMapping(c *auth.Context) {
+	var pms business.ProductMappingSpec
+	err := c.BindParamsFromBody(&pms)
+
+	if err == nil {
+		var id uint
+		id, err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				pm, err := business.UpdateProductMapping(tx, c, id, &pms)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(pm)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func deleteProductMapping(c *auth.Context) {
+	id, err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			pm, err := business.DeleteProductMapping(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnObject(pm)
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
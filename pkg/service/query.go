@@ -0,0 +1,65 @@
+//=============================================================================
+/*
+Copyright © 2026 Andrea Carboni andrea.carboni71@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+//=============================================================================
+
+package service
+
+import (
+	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/req"
+	"github.com/tradalia/inventory-server/pkg/db"
+)
+
+//=============================================================================
+
+func getListQuery(c *auth.Context, fields *db.QueryFields) (*db.ListQuery, error) {
+	return db.ParseListQuery(fields, c.Gin.Request.URL.Query())
+}
+
+//=============================================================================
+//--- Like ReturnList, with the total number of rows matching the query and the
+//--- cursor of the next page, if any
+
+type listResponse struct {
+	Offset      int     `json:"offset"`
+	Limit       int     `json:"limit"`
+	Overflow    bool    `json:"overflow"`
+	Total       int64   `json:"total"`
+	NextCursor  string  `json:"nextCursor,omitempty"`
+	Result      any     `json:"result"`
+}
+
+//=============================================================================
+
+func returnPage(c *auth.Context, result any, offset int, limit int, size int, total int64, nextCursor string) error {
+	return c.ReturnObject(&listResponse{
+		Offset    : offset,
+		Limit     : limit,
+		Overflow  : size == req.MaxQueryLimit,
+		Total     : total,
+		NextCursor: nextCursor,
+		Result    : result,
+	})
+}
+
+//=============================================================================
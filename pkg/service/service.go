@@ -41,35 +41,107 @@ func Init(router *gin.Engine, cfg *app.Config, logger *slog.Logger) {
 
 	//--- Inventory
 
-	router.GET ("/api/inventory/v1/currencies",               ctrl.Secure(getCurrencies,          roles.Admin_User_Service))
-	router.GET ("/api/inventory/v1/exchanges",                ctrl.Secure(getExchanges,           roles.Admin_User_Service))
-
-	router.GET ("/api/inventory/v1/data-products",            ctrl.Secure(getDataProducts,        roles.Admin_User_Service))
-	router.POST("/api/inventory/v1/data-products",            ctrl.Secure(addDataProduct,         roles.Admin_User_Service))
-	router.GET ("/api/inventory/v1/data-products/:id",        ctrl.Secure(getDataProductById,     roles.Admin_User_Service))
-	router.PUT ("/api/inventory/v1/data-products/:id",        ctrl.Secure(updateDataProduct,      roles.Admin_User_Service))
-
-	router.GET ("/api/inventory/v1/broker-products",          ctrl.Secure(getBrokerProducts,      roles.Admin_User_Service))
-	router.POST("/api/inventory/v1/broker-products",          ctrl.Secure(addBrokerProduct,       roles.Admin_User_Service))
-	router.GET ("/api/inventory/v1/broker-products/:id",      ctrl.Secure(getBrokerProductById,   roles.Admin_User_Service))
-	router.PUT ("/api/inventory/v1/broker-products/:id",      ctrl.Secure(updateBrokerProduct,    roles.Admin_User_Service))
-
-	router.GET   ("/api/inventory/v1/trading-systems",              ctrl.Secure(getTradingSystems,      roles.Admin_User_Service))
-	router.POST  ("/api/inventory/v1/trading-systems",              ctrl.Secure(addTradingSystem,       roles.Admin_User_Service))
-	router.PUT   ("/api/inventory/v1/trading-systems/:id",          ctrl.Secure(updateTradingSystem,    roles.Admin_User_Service))
-	router.DELETE("/api/inventory/v1/trading-systems/:id",          ctrl.Secure(deleteTradingSystem,    roles.Admin_User_Service))
-	router.POST  ("/api/inventory/v1/trading-systems/:id/finalize", ctrl.Secure(finalizeTradingSystem,  roles.Admin_User_Service))
-
-	router.GET   ("/api/inventory/v1/trading-sessions",       ctrl.Secure(getTradingSessions,     roles.Admin_User_Service))
-	router.GET   ("/api/inventory/v1/agent-profiles",         ctrl.Secure(getAgentProfiles,       roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/currencies",                               ctrl.Secure(getCurrencies,           roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/currencies/:id/history",                   ctrl.Secure(getCurrencyHistory,      roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/exchanges",                                ctrl.Secure(getExchanges,            roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/data-products",                            ctrl.Secure(getDataProducts,         roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/data-products",                            ctrl.Secure(addDataProduct,          roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/data-products/import",                     ctrl.Secure(importDataProducts,      roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/data-products/:id",                        ctrl.Secure(getDataProductById,      roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/data-products/:id",                        ctrl.Secure(updateDataProduct,       roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/data-products/:id/broker-products",        ctrl.Secure(getMappedBrokerProducts, roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/broker-products",                          ctrl.Secure(getBrokerProducts,       roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/broker-products",                          ctrl.Secure(addBrokerProduct,        roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/broker-products/import",                   ctrl.Secure(importBrokerProducts,    roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/broker-products/:id",                      ctrl.Secure(getBrokerProductById,    roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/broker-products/:id",                      ctrl.Secure(updateBrokerProduct,     roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/broker-products/:id/margins",              ctrl.Secure(getBrokerProductMargins, roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/broker-products/:id/commission",           ctrl.Secure(getCommissionModel,      roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/broker-products/:id/commission",           ctrl.Secure(setCommissionModel,      roles.Admin_User_Service))
+	router.DELETE("/api/inventory/v1/broker-products/:id/commission",           ctrl.Secure(deleteCommissionModel,   roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/broker-products/:id/commission/calculate", ctrl.Secure(calculateCommission,     roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/product-mappings",     ctrl.Secure(getProductMappings,    roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/product-mappings",     ctrl.Secure(addProductMapping,     roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/product-mappings/:id", ctrl.Secure(getProductMappingById, roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/product-mappings/:id", ctrl.Secure(updateProductMapping,  roles.Admin_User_Service))
+	router.DELETE("/api/inventory/v1/product-mappings/:id", ctrl.Secure(deleteProductMapping,  roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/catalog-products",                     ctrl.Secure(getCatalogProducts,          roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/catalog-products/:id",                 ctrl.Secure(getCatalogProductById,       roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/catalog-products/:id/data-products",   ctrl.Secure(addDataProductFromCatalog,   roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/catalog-products/:id/broker-products", ctrl.Secure(addBrokerProductFromCatalog, roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/trading-systems",                 ctrl.Secure(getTradingSystems,           roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/trading-systems",                 ctrl.Secure(addTradingSystem,            roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/trading-systems/duplicate-refs",  ctrl.Secure(getExternalRefDuplicates,    roles.Admin))
+	router.PUT   ("/api/inventory/v1/trading-systems/:id",             ctrl.Secure(updateTradingSystem,         roles.Admin_User_Service))
+	router.DELETE("/api/inventory/v1/trading-systems/:id",             ctrl.Secure(deleteTradingSystem,         roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/trading-systems/:id/clone",       ctrl.Secure(cloneTradingSystem,          roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/trading-systems/:id/finalize",    ctrl.Secure(finalizeTradingSystem,       roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/trading-systems/:id/transition",  ctrl.Secure(transitionTradingSystem,     roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/trading-systems/:id/transitions", ctrl.Secure(getTradingSystemTransitions, roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/trading-systems/:id/versions",    ctrl.Secure(getTradingSystemVersions,    roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/trading-systems/:id/as-of",       ctrl.Secure(getTradingSystemAsOf,        roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/portfolios",     ctrl.Secure(getPortfolios,    roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/portfolios",     ctrl.Secure(addPortfolio,     roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/portfolios/:id", ctrl.Secure(getPortfolioById, roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/portfolios/:id", ctrl.Secure(updatePortfolio,  roles.Admin_User_Service))
+	router.DELETE("/api/inventory/v1/portfolios/:id", ctrl.Secure(deletePortfolio,  roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/trading-sessions",               ctrl.Secure(getTradingSessions,        roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/agent-profiles",                 ctrl.Secure(getAgentProfiles,          roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/agent-profiles/:id/scan-result", ctrl.Secure(getAgentProfileScanResult, roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/strategy-types",                 ctrl.Secure(getStrategyTypes,          roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/tags",                           ctrl.Secure(getTags,                   roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/tags/:id",                       ctrl.Secure(renameTag,                 roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/tags/:id/merge",                 ctrl.Secure(mergeTags,                 roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/tags/backfill",                  ctrl.Secure(backfillTags,              roles.Admin))
+
+	router.GET   ("/api/inventory/v1/inventory/export",               ctrl.Secure(exportInventory,           roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/inventory/import",               ctrl.Secure(importInventory,           roles.Admin_User_Service))
+
+	router.GET   ("/api/inventory/v1/preferences",                    ctrl.Secure(getUserPreferences,        roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/preferences",                    ctrl.Secure(setUserPreferences,        roles.Admin_User_Service))
 
 	//--- Administration
 
-	router.GET   ("/api/inventory/v1/connections",         ctrl.Secure(getConnections,      roles.Admin_User_Service))
-	router.GET   ("/api/inventory/v1/connections/:id",     ctrl.Secure(getConnectionById,   roles.Admin_User_Service))
-	router.POST  ("/api/inventory/v1/connections",         ctrl.Secure(addConnection,       roles.Admin_User_Service))
-	router.PUT   ("/api/inventory/v1/connections/:id",     ctrl.Secure(updateConnection,    roles.Admin_User_Service))
-	router.DELETE("/api/inventory/v1/connections/:id",     ctrl.Secure(deleteConnection,    roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections",                                 ctrl.Secure(getConnections,                   roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id",                             ctrl.Secure(getConnectionById,                roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/connections",                                 ctrl.Secure(addConnection,                    roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/connections/reencrypt",                       ctrl.Secure(reencryptConnections,             roles.Admin))
+	router.PUT   ("/api/inventory/v1/connections/:id",                             ctrl.Secure(updateConnection,                 roles.Admin_User_Service))
+	router.DELETE("/api/inventory/v1/connections/:id",                             ctrl.Secure(deleteConnection,                 roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id/config",                      ctrl.Secure(getConnectionConfig,              roles.Service))
+	router.POST  ("/api/inventory/v1/connections/:id/connect",                     ctrl.Secure(connectConnection,                roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/connections/:id/disconnect",                  ctrl.Secure(disconnectConnection,             roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id/status-history",              ctrl.Secure(getConnectionStatusHistory,       roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id/uptime",                      ctrl.Secure(getConnectionUptime,              roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id/instruments",                 ctrl.Secure(getConnectionInstruments,         roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/connections/:id/instruments/data-products",   ctrl.Secure(addDataProductsFromInstruments,   roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/connections/:id/instruments/broker-products", ctrl.Secure(addBrokerProductsFromInstruments, roles.Admin_User_Service))
+	router.GET   ("/api/inventory/v1/connections/:id/instruments/drift",           ctrl.Secure(getInstrumentDrift,               roles.Admin_User_Service))
+
+	router.POST  ("/api/inventory/v1/catalog-products",                     ctrl.Secure(addCatalogProduct,           roles.Admin))
+	router.PUT   ("/api/inventory/v1/catalog-products/:id",                 ctrl.Secure(updateCatalogProduct,        roles.Admin))
+	router.DELETE("/api/inventory/v1/catalog-products/:id",                 ctrl.Secure(deleteCatalogProduct,        roles.Admin))
+
+	router.GET   ("/api/inventory/v1/currency-reviews",              ctrl.Secure(getCurrencyReviews,     roles.Admin))
+	router.POST  ("/api/inventory/v1/currency-reviews/:id/accept",   ctrl.Secure(acceptCurrencyReview,   roles.Admin))
+	router.POST  ("/api/inventory/v1/currency-reviews/:id/override", ctrl.Secure(overrideCurrencyReview, roles.Admin))
+
+	router.GET   ("/api/inventory/v1/exchanges/:id",       ctrl.Secure(getExchangeById,     roles.Admin_User_Service))
+	router.POST  ("/api/inventory/v1/exchanges",                                ctrl.Secure(addExchange,             roles.Admin))
+	router.PUT   ("/api/inventory/v1/exchanges/:id",       ctrl.Secure(updateExchange,      roles.Admin))
+	router.DELETE("/api/inventory/v1/exchanges/:id",       ctrl.Secure(deleteExchange,      roles.Admin))
+
+	router.GET   ("/api/inventory/v1/exchanges/:id/calendar",        ctrl.Secure(getExchangeCalendar,    roles.Admin_User_Service))
+	router.PUT   ("/api/inventory/v1/exchanges/:id/hours",           ctrl.Secure(setExchangeHours,       roles.Admin))
+	router.POST  ("/api/inventory/v1/exchanges/:id/holidays/import", ctrl.Secure(importExchangeHolidays, roles.Admin))
 }
 
 //=============================================================================
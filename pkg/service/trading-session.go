@@ -39,13 +39,13 @@ func getTradingSessions(c *auth.Context) {
 
 	if err == nil {
 		err = db.RunInTransaction(func(tx *gorm.DB) error {
-			list, err := business.GetTradingSessions(tx, c, filter, offset, limit)
+			list, total, err := business.GetTradingSessions(tx, c, filter, offset, limit)
 
 			if err != nil {
 				return err
 			}
 
-			return c.ReturnList(list, offset, limit, len(*list))
+			return returnPage(c, list, offset, limit, len(*list), total, "")
 		})
 	}
 
@@ -26,6 +26,8 @@ package service
 
 import (
 	"github.com/tradalia/core/auth"
+	"github.com/tradalia/core/datatype"
+	"github.com/tradalia/core/req"
 	"github.com/tradalia/inventory-server/pkg/business"
 	"github.com/tradalia/inventory-server/pkg/db"
 	"gorm.io/gorm"
@@ -34,23 +36,32 @@ import (
 //=============================================================================
 
 func getTradingSystems(c *auth.Context) {
-	filter := map[string]any{}
 	offset, limit, err := c.GetPagingParams()
 
 	if err == nil {
-		var details bool
-		details, err = c.GetParamAsBool("details", false)
+		var q *db.ListQuery
+		q, err = getListQuery(c, &db.TradingSystemQueryFields)
 
 		if err == nil {
-			err = db.RunInTransaction(func(tx *gorm.DB) error {
-				list, err := business.GetTradingSystems(tx, c, filter, offset, limit, details)
+			var details bool
+			details, err = c.GetParamAsBool("details", false)
 
-				if err != nil {
-					return err
-				}
+			if err == nil {
+				err = db.RunInTransaction(func(tx *gorm.DB) error {
+					list, total, err := business.GetTradingSystems(tx, c, q, offset, limit, details)
 
-				return c.ReturnList(list, offset, limit, len(*list))
-			})
+					if err != nil {
+						return err
+					}
+
+					nextCursor := ""
+					if size := len(*list); size > 0 {
+						nextCursor = q.NextCursor((*list)[size -1].Id, size, limit)
+					}
+
+					return returnPage(c, list, offset, limit, len(*list), total, nextCursor)
+				})
+			}
 		}
 	}
 
@@ -127,6 +138,48 @@ func deleteTradingSystem(c *auth.Context) {
 
 //=============================================================================
 
+func cloneTradingSystem(c *auth.Context) {
+	var tscs business.TradingSystemCloneSpec
+	err := c.BindParamsFromBody(&tscs)
+
+	if err == nil {
+		var id uint
+		id,err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				ts, err := business.CloneTradingSystem(tx, c, id, &tscs)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(ts)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getExternalRefDuplicates(c *auth.Context) {
+	err := db.RunInTransaction(func(tx *gorm.DB) error {
+		list, err := business.GetExternalRefDuplicates(tx, c)
+
+		if err != nil {
+			return err
+		}
+
+		return c.ReturnList(list, 0, len(*list), len(*list))
+	})
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
 func finalizeTradingSystem(c *auth.Context) {
 	id,err := c.GetIdFromUrl()
 
@@ -145,3 +198,96 @@ func finalizeTradingSystem(c *auth.Context) {
 }
 
 //=============================================================================
+
+func transitionTradingSystem(c *auth.Context) {
+	var tsts business.TradingSystemTransitionSpec
+	err := c.BindParamsFromBody(&tsts)
+
+	if err == nil {
+		var id uint
+		id,err = c.GetIdFromUrl()
+
+		if err == nil {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				ts, err := business.TransitionTradingSystem(tx, c, id, &tsts)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(ts)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getTradingSystemTransitions(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, err := business.GetTradingSystemTransitions(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnList(list, 0, len(*list), len(*list))
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getTradingSystemVersions(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		err = db.RunInTransaction(func(tx *gorm.DB) error {
+			list, err := business.GetTradingSystemVersions(tx, c, id)
+
+			if err != nil {
+				return err
+			}
+
+			return c.ReturnList(list, 0, len(*list), len(*list))
+		})
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================
+
+func getTradingSystemAsOf(c *auth.Context) {
+	id,err := c.GetIdFromUrl()
+
+	if err == nil {
+		var date datatype.IntDate
+		date, err = datatype.ParseIntDate(c.GetParamAsString("date", ""), true)
+
+		if err != nil {
+			err = req.NewBadRequestError("Invalid 'date' param: %v", err.Error())
+		} else {
+			err = db.RunInTransaction(func(tx *gorm.DB) error {
+				tsv, err := business.GetTradingSystemAsOf(tx, c, id, date)
+
+				if err != nil {
+					return err
+				}
+
+				return c.ReturnObject(tsv)
+			})
+		}
+	}
+
+	c.ReturnError(err)
+}
+
+//=============================================================================